@@ -63,6 +63,13 @@ func parseOptions() (options server.Options, err error) {
 	adminPassword := viper.GetString("admin-password")
 	maintenance := viper.GetBool("maintenance")
 	signingKey := viper.GetString("signingKey")
+	shutdownTimeout := viper.GetDuration("shutdown-timeout")
+	dbMaxBytes := viper.GetInt64("db-max-bytes")
+	dbMaxEntries := viper.GetUint64("db-max-entries")
+	backupDir := viper.GetString("backup-dir")
+	backupInterval := viper.GetDuration("backup-interval")
+	backupKeep := viper.GetInt("backup-keep")
+	backupMaxAge := viper.GetDuration("backup-max-age")
 	options = server.
 		DefaultOptions().
 		WithDir(dir).
@@ -79,7 +86,10 @@ func parseOptions() (options server.Options, err error) {
 		WithDevMode(devMode).
 		WithAdminPassword(adminPassword).
 		WithMaintenance(maintenance).
-		WithSigningKey(signingKey)
+		WithSigningKey(signingKey).
+		WithShutdownTimeout(shutdownTimeout).
+		WithDbQuota(dbMaxBytes, dbMaxEntries).
+		WithBackups(backupDir, backupInterval, backupKeep, backupMaxAge)
 	if mtls {
 		// todo https://golang.org/src/crypto/x509/root_linux.go
 		options.MTLsOptions = server.DefaultMTLsOptions().
@@ -110,6 +120,13 @@ func (cl *Commandline) setupFlags(cmd *cobra.Command, options server.Options, mt
 	cmd.Flags().String("admin-password", options.AdminPassword, "admin password (default is 'immudb') as plain-text or base64 encoded (must be prefixed with 'enc:' if it is encoded)")
 	cmd.Flags().Bool("maintenance", options.GetMaintenance(), "override the authentication flag")
 	cmd.Flags().String("signingKey", options.SigningKey, "signature private key path. If a valid one is provided, it enables the cryptographic signature of the root. E.g. \"./../test/signer/ec3.key\"")
+	cmd.Flags().Int64("db-max-bytes", options.DbMaxBytes, "storage quota in bytes enforced on each user database, 0 means unlimited")
+	cmd.Flags().Uint64("db-max-entries", options.DbMaxEntries, "maximum number of entries of each user database, 0 means unlimited")
+	cmd.Flags().String("backup-dir", options.BackupDir, "directory where scheduled backups are written, one subdirectory per database")
+	cmd.Flags().Duration("backup-interval", options.BackupInterval, "interval between scheduled backups of each database, 0 disables them")
+	cmd.Flags().Int("backup-keep", options.BackupKeep, "number of backups kept for each database, 0 keeps all of them")
+	cmd.Flags().Duration("backup-max-age", options.BackupMaxAge, "age after which backups are removed, the most recent one is always kept, 0 disables it")
+	cmd.Flags().Duration("shutdown-timeout", options.ShutdownTimeout, "time to wait for pending requests to complete on shutdown before forcing it")
 }
 
 func setupDefaults(options server.Options, mtlsOptions server.MTLsOptions) {
@@ -130,4 +147,11 @@ func setupDefaults(options server.Options, mtlsOptions server.MTLsOptions) {
 	viper.SetDefault("devmode", options.DevMode)
 	viper.SetDefault("admin-password", options.AdminPassword)
 	viper.SetDefault("maintenance", options.GetMaintenance())
+	viper.SetDefault("shutdown-timeout", options.ShutdownTimeout)
+	viper.SetDefault("db-max-bytes", options.DbMaxBytes)
+	viper.SetDefault("db-max-entries", options.DbMaxEntries)
+	viper.SetDefault("backup-dir", options.BackupDir)
+	viper.SetDefault("backup-interval", options.BackupInterval)
+	viper.SetDefault("backup-keep", options.BackupKeep)
+	viper.SetDefault("backup-max-age", options.BackupMaxAge)
 }
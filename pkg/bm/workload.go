@@ -0,0 +1,113 @@
+/*
+Copyright 2019-2020 vChain, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bm
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/store"
+)
+
+// maxBatchSize keeps batches below the badger "Txn is too big to fit into one request" limit
+const maxBatchSize = 10_000
+
+// Key returns the key used by the workloads for the i-th entry
+func Key(i int) []byte {
+	return []byte(strconv.FormatUint(uint64(i), 10))
+}
+
+// Populate sets the entries [0, n) having the given value, to be read by the read workloads
+func Populate(st *store.Store, n int, value []byte) error {
+	for start := 0; start < n; start += maxBatchSize {
+		end := start + maxBatchSize
+		if end > n {
+			end = n
+		}
+		list := schema.KVList{}
+		for i := start; i < end; i++ {
+			list.KVs = append(list.KVs, &schema.KeyValue{Key: Key(i), Value: value})
+		}
+		if _, err := st.SetBatch(list); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SequentialWrite returns a workload committing one entry per Set, measuring commit throughput
+func SequentialWrite(value []byte, options ...store.WriteOption) func(bm *Bm, start int, end int) error {
+	return func(bm *Bm, start int, end int) error {
+		for i := start; i < end; i++ {
+			if _, err := bm.Store.Set(schema.KeyValue{Key: Key(i), Value: value}, options...); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// BatchWrite returns a workload committing entries in batches of batchSize
+func BatchWrite(value []byte, batchSize int, options ...store.WriteOption) func(bm *Bm, start int, end int) error {
+	if batchSize <= 0 || batchSize > maxBatchSize {
+		batchSize = maxBatchSize
+	}
+	return func(bm *Bm, start int, end int) error {
+		list := schema.KVList{}
+		for i := start; i < end; i++ {
+			list.KVs = append(list.KVs, &schema.KeyValue{Key: Key(i), Value: value})
+			if len(list.KVs) == batchSize || i == end-1 {
+				if _, err := bm.Store.SetBatch(list, options...); err != nil {
+					return err
+				}
+				list = schema.KVList{}
+			}
+		}
+		return nil
+	}
+}
+
+// SafeRead returns a workload fetching and verifying the inclusion proof of the entries [0, n),
+// measuring proof latency. Entries must have been set in advance, eg. by Populate.
+func SafeRead(n int) func(bm *Bm, start int, end int) error {
+	return func(bm *Bm, start int, end int) error {
+		for i := start; i < end; i++ {
+			item, err := bm.Store.SafeGet(schema.SafeGetOptions{Key: Key(i % n)})
+			if err != nil {
+				return err
+			}
+			if !item.Proof.Verify(item.Item.Hash(), schema.Root{}) {
+				return fmt.Errorf("proof verification failed for key %s", Key(i%n))
+			}
+		}
+		return nil
+	}
+}
+
+// ScanRead returns a workload scanning limit entries at a time, measuring scan throughput.
+// Each iteration returns one page, starting from a key among the entries [0, n) set in advance.
+func ScanRead(n int, limit uint64) func(bm *Bm, start int, end int) error {
+	return func(bm *Bm, start int, end int) error {
+		for i := start; i < end; i++ {
+			if _, err := bm.Store.Scan(schema.ScanOptions{Offset: Key(i % n), Limit: limit}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2019-2020 vChain, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bm
+
+import (
+	"testing"
+
+	"github.com/codenotary/immudb/pkg/store"
+)
+
+var value = []byte{0, 1, 3, 4, 5, 6, 7}
+
+const readEntries = 10_000
+
+func benchmark(b *testing.B, populate bool, work func(bm *Bm, start int, end int) error) {
+	st, closer := makeStore()
+	defer closer()
+
+	if populate {
+		if err := Populate(st, readEntries, value); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	b.ResetTimer()
+	if err := work(&Bm{Store: st}, 0, b.N); err != nil {
+		b.Fatal(err)
+	}
+}
+
+func BenchmarkSequentialWrite(b *testing.B) {
+	benchmark(b, false, SequentialWrite(value))
+}
+
+func BenchmarkSequentialWriteAsyncCommit(b *testing.B) {
+	benchmark(b, false, SequentialWrite(value, store.WithAsyncCommit(true)))
+}
+
+func BenchmarkBatchWrite(b *testing.B) {
+	benchmark(b, false, BatchWrite(value, 1000))
+}
+
+func BenchmarkSafeRead(b *testing.B) {
+	benchmark(b, true, SafeRead(readEntries))
+}
+
+func BenchmarkScanRead(b *testing.B) {
+	benchmark(b, true, ScanRead(readEntries, 100))
+}
+
+func TestWorkloads(t *testing.T) {
+	bm := &Bm{
+		CreateStore: true,
+		Concurrency: 2,
+		Iterations:  100,
+		Before: func(bm *Bm) {
+			if err := Populate(bm.Store, 50, value); err != nil {
+				t.Fatal(err)
+			}
+		},
+	}
+	for _, work := range []func(bm *Bm, start int, end int) error{
+		BatchWrite(value, 10),
+		SafeRead(50),
+		ScanRead(50, 10),
+	} {
+		bm.Work = work
+		res := bm.Execute()
+		if res.Transactions <= 0 {
+			t.Fatalf("unexpected throughput %f", res.Transactions)
+		}
+	}
+}
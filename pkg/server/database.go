@@ -17,6 +17,7 @@ limitations under the License.
 package server
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -52,9 +53,13 @@ func OpenDb(op *DbOptions, log logger.Logger) (*Db, error) {
 		return nil, fmt.Errorf("Missing database directories")
 	}
 
-	db.Store, err = store.Open(store.DefaultOptions(dbDir, db.Logger))
+	storeOpts, badgerOpts := store.DefaultOptions(dbDir, db.Logger)
+	db.Store, err = store.Open(storeOpts.WithQuota(op.GetQuota()), badgerOpts)
+	if err != nil {
+		return db, logErr(db.Logger, "Unable to open store: %s", err)
+	}
 
-	return db, logErr(db.Logger, "Unable to open store: %s", err)
+	return db, logErr(db.Logger, "Unable to schedule backups: %s", db.scheduleBackups())
 }
 
 // NewDb Creates a new Database along with it's directories and files
@@ -70,8 +75,11 @@ func NewDb(op *DbOptions, log logger.Logger) (*Db, error) {
 		db.Logger.Infof("Starting with in memory store")
 		storeOpts, badgerOpts := store.DefaultOptions("", db.Logger)
 		badgerOpts = badgerOpts.WithInMemory(true)
-		db.Store, err = store.Open(storeOpts, badgerOpts)
-		return db, logErr(db.Logger, "Unable to open store: %s", err)
+		db.Store, err = store.Open(storeOpts.WithQuota(op.GetQuota()), badgerOpts)
+		if err != nil {
+			return db, logErr(db.Logger, "Unable to open store: %s", err)
+		}
+		return db, logErr(db.Logger, "Unable to schedule backups: %s", db.scheduleBackups())
 	}
 
 	dbDir := filepath.Join(op.GetDbRootPath(), op.GetDbName())
@@ -84,8 +92,24 @@ func NewDb(op *DbOptions, log logger.Logger) (*Db, error) {
 		return nil, logErr(db.Logger, "Unable to create data folder: %s", err)
 	}
 
-	db.Store, err = store.Open(store.DefaultOptions(dbDir, db.Logger))
-	return db, logErr(db.Logger, "Unable to open store: %s", err)
+	storeOpts, badgerOpts := store.DefaultOptions(dbDir, db.Logger)
+	db.Store, err = store.Open(storeOpts.WithQuota(op.GetQuota()), badgerOpts)
+	if err != nil {
+		return db, logErr(db.Logger, "Unable to open store: %s", err)
+	}
+
+	return db, logErr(db.Logger, "Unable to schedule backups: %s", db.scheduleBackups())
+}
+
+// scheduleBackups starts the periodic backups of the database, if enabled.
+// The scheduler is stopped when the store is closed.
+func (d *Db) scheduleBackups() error {
+	schedule := d.options.GetBackupSchedule()
+	if schedule.Interval <= 0 {
+		return nil
+	}
+	_, err := d.Store.ScheduleBackups(schedule)
+	return err
 }
 
 //Set ...
@@ -180,8 +204,8 @@ func (d *Db) BySafeIndex(sio *schema.SafeIndexOptions) (*schema.SafeItem, error)
 }
 
 //History ...
-func (d *Db) History(options *schema.HistoryOptions) (*schema.ItemList, error) {
-	return d.Store.History(options)
+func (d *Db) History(ctx context.Context, options *schema.HistoryOptions) (*schema.ItemList, error) {
+	return d.Store.HistoryContext(ctx, options)
 }
 
 //Health ...
@@ -207,8 +231,8 @@ func (d *Db) ZAdd(opts *schema.ZAddOptions) (*schema.Index, error) {
 }
 
 // ZScan ...
-func (d *Db) ZScan(opts *schema.ZScanOptions) (*schema.ZItemList, error) {
-	return d.Store.ZScan(*opts)
+func (d *Db) ZScan(ctx context.Context, opts *schema.ZScanOptions) (*schema.ZItemList, error) {
+	return d.Store.ZScanContext(ctx, *opts)
 }
 
 //SafeZAdd ...
@@ -217,13 +241,13 @@ func (d *Db) SafeZAdd(opts *schema.SafeZAddOptions) (*schema.Proof, error) {
 }
 
 //Scan ...
-func (d *Db) Scan(opts *schema.ScanOptions) (*schema.ItemList, error) {
-	return d.Store.Scan(*opts)
+func (d *Db) Scan(ctx context.Context, opts *schema.ScanOptions) (*schema.ItemList, error) {
+	return d.Store.ScanContext(ctx, *opts)
 }
 
 //IScan ...
-func (d *Db) IScan(opts *schema.IScanOptions) (*schema.Page, error) {
-	return d.Store.IScan(*opts)
+func (d *Db) IScan(ctx context.Context, opts *schema.IScanOptions) (*schema.Page, error) {
+	return d.Store.IScanContext(ctx, *opts)
 }
 
 //Dump ...
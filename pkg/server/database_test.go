@@ -18,7 +18,9 @@ package server
 
 import (
 	"bytes"
+	"context"
 	"github.com/stretchr/testify/assert"
+	"io/ioutil"
 	"log"
 	"os"
 	"path"
@@ -469,7 +471,7 @@ func TestHistory(t *testing.T) {
 	}
 	time.Sleep(1 * time.Second)
 
-	inc, err := db.History(&schema.HistoryOptions{
+	inc, err := db.History(context.Background(), &schema.HistoryOptions{
 		Key: kv[0].Key,
 	})
 	if err != nil {
@@ -540,7 +542,7 @@ func TestZAdd(t *testing.T) {
 	if ref.Index != 1 {
 		t.Fatalf("Reference, expected %v, got %v", 1, ref.Index)
 	}
-	item, err := db.ZScan(&schema.ZScanOptions{
+	item, err := db.ZScan(context.Background(), &schema.ZScanOptions{
 		Set:     []byte(`mySet`),
 		Offset:  []byte(""),
 		Limit:   3,
@@ -590,7 +592,7 @@ func TestScan(t *testing.T) {
 		t.Fatalf("SafeZAdd index, expected %v, got %v", 2, it.Index)
 	}
 
-	item, err := db.Scan(&schema.ScanOptions{
+	item, err := db.Scan(context.Background(), &schema.ScanOptions{
 		Offset: nil,
 		Deep:   false,
 		Limit:  1,
@@ -604,7 +606,7 @@ func TestScan(t *testing.T) {
 		t.Fatalf("Reference, expected %v, got %v", string(kv[0].Value), string(item.Items[0].Value))
 	}
 
-	scanItem, err := db.IScan(&schema.IScanOptions{
+	scanItem, err := db.IScan(context.Background(), &schema.IScanOptions{
 		PageNumber: 2,
 		PageSize:   1,
 	})
@@ -811,3 +813,40 @@ func TestDb_SetBatchAtomicOperations(t *testing.T) {
 
 	require.NoError(t, err)
 }
+
+func TestDbQuota(t *testing.T) {
+	options := DefaultOption().WithDbName("db_quota").WithInMemoryStore(true).WithCorruptionChecker(false).
+		WithQuota(store.Quota{MaxEntries: 1})
+	db, err := NewDb(options, logger.NewSimpleLogger("immudb ", os.Stderr))
+	require.NoError(t, err)
+	defer db.Store.Close()
+
+	_, err = db.Set(&schema.KeyValue{Key: []byte(`k1`), Value: []byte(`v1`)})
+	assert.NoError(t, err)
+	_, err = db.Set(&schema.KeyValue{Key: []byte(`k2`), Value: []byte(`v2`)})
+	assert.Equal(t, store.ErrQuotaExceeded, err)
+}
+
+func TestDbBackupSchedule(t *testing.T) {
+	dir, err := ioutil.TempDir("", "immudb_backups")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	options := DefaultOption().WithDbName("db_backup").WithInMemoryStore(true).WithCorruptionChecker(false).
+		WithBackupSchedule(store.BackupSchedule{Dir: dir, Interval: 10 * time.Millisecond})
+	db, err := NewDb(options, logger.NewSimpleLogger("immudb ", os.Stderr))
+	require.NoError(t, err)
+	defer db.Store.Close()
+
+	_, err = db.Set(&schema.KeyValue{Key: []byte(`k1`), Value: []byte(`v1`)})
+	require.NoError(t, err)
+
+	var manifests []*store.BackupManifest
+	for deadline := time.Now().Add(5 * time.Second); len(manifests) == 0 && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+		manifests, err = store.ListBackups(dir)
+		require.NoError(t, err)
+	}
+	require.NotEmpty(t, manifests)
+	assert.Equal(t, uint64(0), manifests[0].Index)
+}
@@ -16,6 +16,8 @@ limitations under the License.
 
 package server
 
+import "github.com/codenotary/immudb/pkg/store"
+
 //DbOptions database instance options
 type DbOptions struct {
 	//	dbDir             string
@@ -23,6 +25,8 @@ type DbOptions struct {
 	dbRootPath        string
 	corruptionChecker bool
 	inMemoryStore     bool
+	quota             store.Quota
+	backupSchedule    store.BackupSchedule
 }
 
 // DefaultOption Initialise Db Optionts to default values
@@ -79,3 +83,25 @@ func (o *DbOptions) WithInMemoryStore(inmemory bool) *DbOptions {
 func (o *DbOptions) GetInMemoryStore() bool {
 	return o.inMemoryStore
 }
+
+// WithQuota sets the storage quota enforced on this database instance
+func (o *DbOptions) WithQuota(quota store.Quota) *DbOptions {
+	o.quota = quota
+	return o
+}
+
+// GetQuota returns the storage quota enforced on this database instance
+func (o *DbOptions) GetQuota() store.Quota {
+	return o.quota
+}
+
+// WithBackupSchedule sets the periodic backups of this database instance, a zero interval disables them
+func (o *DbOptions) WithBackupSchedule(schedule store.BackupSchedule) *DbOptions {
+	o.backupSchedule = schedule
+	return o
+}
+
+// GetBackupSchedule returns the periodic backups of this database instance
+func (o *DbOptions) GetBackupSchedule() store.BackupSchedule {
+	return o.backupSchedule
+}
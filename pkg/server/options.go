@@ -19,10 +19,13 @@ package server
 import (
 	"fmt"
 	"net"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/codenotary/immudb/pkg/auth"
+	"github.com/codenotary/immudb/pkg/store"
 )
 
 const SystemdbName = "systemdb"
@@ -55,6 +58,13 @@ type Options struct {
 	usingCustomListener bool
 	maintenance         bool
 	SigningKey          string
+	ShutdownTimeout     time.Duration
+	DbMaxBytes          int64
+	DbMaxEntries        uint64
+	BackupDir           string
+	BackupInterval      time.Duration
+	BackupKeep          int
+	BackupMaxAge        time.Duration
 }
 
 // DefaultOptions returns default server options
@@ -82,6 +92,8 @@ func DefaultOptions() Options {
 		inMemoryStore:       false,
 		usingCustomListener: false,
 		maintenance:         false,
+		ShutdownTimeout:     30 * time.Second,
+		BackupDir:           "./backups",
 	}
 }
 
@@ -215,6 +227,19 @@ func (o Options) String() string {
 	opts = append(opts, rightPad("Dev mode", o.DevMode))
 	opts = append(opts, rightPad("Default database", o.defaultDbName))
 	opts = append(opts, rightPad("Maintenance mode", o.maintenance))
+	opts = append(opts, rightPad("Shutdown timeout", o.ShutdownTimeout))
+	if o.DbMaxBytes > 0 {
+		opts = append(opts, rightPad("Db max bytes", o.DbMaxBytes))
+	}
+	if o.DbMaxEntries > 0 {
+		opts = append(opts, rightPad("Db max entries", o.DbMaxEntries))
+	}
+	if o.BackupInterval > 0 {
+		opts = append(opts, rightPad("Backup dir", o.BackupDir))
+		opts = append(opts, rightPad("Backup interval", o.BackupInterval))
+		opts = append(opts, rightPad("Backup keep", o.BackupKeep))
+		opts = append(opts, rightPad("Backup max age", o.BackupMaxAge))
+	}
 	opts = append(opts, "----------------------------------------")
 	opts = append(opts, "Superadmin default credentials")
 	opts = append(opts, rightPad("   Username", auth.SysAdminUsername))
@@ -285,3 +310,43 @@ func (o Options) WithSigningKey(signingKey string) Options {
 	o.SigningKey = signingKey
 	return o
 }
+
+// WithShutdownTimeout sets how long Stop waits for pending requests to complete before forcing the shutdown
+func (o Options) WithShutdownTimeout(timeout time.Duration) Options {
+	o.ShutdownTimeout = timeout
+	return o
+}
+
+// WithDbQuota sets the storage quota enforced on each user database, zero limits are not enforced
+func (o Options) WithDbQuota(maxBytes int64, maxEntries uint64) Options {
+	o.DbMaxBytes = maxBytes
+	o.DbMaxEntries = maxEntries
+	return o
+}
+
+// GetDbQuota returns the storage quota enforced on each user database
+func (o Options) GetDbQuota() store.Quota {
+	return store.Quota{MaxBytes: o.DbMaxBytes, MaxEntries: o.DbMaxEntries}
+}
+
+// WithBackups sets the directory and the interval of the scheduled backups of each database, and how many archives are kept.
+// A zero interval disables the scheduled backups, zero keep and maxAge disable the corresponding retention rule.
+func (o Options) WithBackups(dir string, interval time.Duration, keep int, maxAge time.Duration) Options {
+	o.BackupDir = dir
+	o.BackupInterval = interval
+	o.BackupKeep = keep
+	o.BackupMaxAge = maxAge
+	return o
+}
+
+// GetBackupSchedule returns the backup schedule of the database named dbName, archives are written to a subdirectory of BackupDir
+func (o Options) GetBackupSchedule(dbName string) store.BackupSchedule {
+	if o.BackupInterval <= 0 {
+		return store.BackupSchedule{}
+	}
+	return store.BackupSchedule{
+		Dir:       filepath.Join(o.BackupDir, dbName),
+		Interval:  o.BackupInterval,
+		Retention: store.BackupRetention{KeepLast: o.BackupKeep, MaxAge: o.BackupMaxAge},
+	}
+}
@@ -17,7 +17,9 @@ limitations under the License.
 package server
 
 import (
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/codenotary/immudb/pkg/auth"
 )
@@ -42,7 +44,8 @@ func TestOptions(t *testing.T) {
 		op.MetricsPort != 9497 ||
 		op.Config != "configs/immudb.toml" ||
 		op.Pidfile != "" ||
-		op.Logfile != "" {
+		op.Logfile != "" ||
+		op.ShutdownTimeout != 30*time.Second {
 		t.Errorf("database default options mismatch")
 	}
 }
@@ -51,7 +54,9 @@ func TestSetOptions(t *testing.T) {
 		WithAddress("localhost").WithPort(2048).
 		WithPidfile("immu.pid").WithMTLs(true).WithAuth(false).
 		WithDetached(true).WithNoHistograms(true).WithMetricsServer(false).
-		WithDevMode(true).WithLogfile("logfile").WithAdminPassword("admin")
+		WithDevMode(true).WithLogfile("logfile").WithAdminPassword("admin").
+		WithShutdownTimeout(time.Second).WithDbQuota(1024, 10).
+		WithBackups("backups", time.Hour, 3, 24*time.Hour)
 	if op.GetAuth() != false ||
 		op.Dir != "immudb_dir" ||
 		op.Network != "udp" ||
@@ -67,6 +72,13 @@ func TestSetOptions(t *testing.T) {
 		op.DevMode != true ||
 		op.Logfile != "logfile" ||
 		op.AdminPassword != "admin" ||
+		op.ShutdownTimeout != time.Second ||
+		op.GetDbQuota().MaxBytes != 1024 ||
+		op.GetDbQuota().MaxEntries != 10 ||
+		op.GetBackupSchedule("db").Dir != filepath.Join("backups", "db") ||
+		op.GetBackupSchedule("db").Interval != time.Hour ||
+		op.GetBackupSchedule("db").Retention.KeepLast != 3 ||
+		op.GetBackupSchedule("db").Retention.MaxAge != 24*time.Hour ||
 		op.Bind() != "localhost:2048" {
 		t.Errorf("database default options mismatch")
 	}
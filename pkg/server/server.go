@@ -302,7 +302,8 @@ func (s *ImmuServer) loadSystemDatabase(dataDir string, adminPassword string) er
 				WithDbName(s.Options.GetSystemAdminDbName()).
 				WithDbRootPath(dataDir).
 				WithCorruptionChecker(s.Options.CorruptionCheck).
-				WithInMemoryStore(s.Options.GetInMemoryStore()).WithDbRootPath(s.Options.Dir)
+				WithInMemoryStore(s.Options.GetInMemoryStore()).WithDbRootPath(s.Options.Dir).
+				WithBackupSchedule(s.Options.GetBackupSchedule(s.Options.GetSystemAdminDbName()))
 
 			db, err := NewDb(op, s.Logger)
 			if err != nil {
@@ -322,7 +323,8 @@ func (s *ImmuServer) loadSystemDatabase(dataDir string, adminPassword string) er
 		op := DefaultOption().
 			WithDbName(s.Options.GetSystemAdminDbName()).
 			WithDbRootPath(dataDir).
-			WithCorruptionChecker(s.Options.CorruptionCheck).WithDbRootPath(s.Options.Dir)
+			WithCorruptionChecker(s.Options.CorruptionCheck).WithDbRootPath(s.Options.Dir).
+			WithBackupSchedule(s.Options.GetBackupSchedule(s.Options.GetSystemAdminDbName()))
 
 		db, err := OpenDb(op, s.Logger)
 		if err != nil {
@@ -349,7 +351,9 @@ func (s *ImmuServer) loadDefaultDatabase(dataDir string) error {
 			WithDbName(s.Options.GetDefaultDbName()).
 			WithDbRootPath(dataDir).
 			WithCorruptionChecker(s.Options.CorruptionCheck).
-			WithInMemoryStore(s.Options.GetInMemoryStore()).WithDbRootPath(s.Options.Dir)
+			WithInMemoryStore(s.Options.GetInMemoryStore()).WithDbRootPath(s.Options.Dir).
+			WithQuota(s.Options.GetDbQuota()).
+			WithBackupSchedule(s.Options.GetBackupSchedule(s.Options.GetDefaultDbName()))
 
 		db, err := NewDb(op, s.Logger)
 		if err != nil {
@@ -362,7 +366,9 @@ func (s *ImmuServer) loadDefaultDatabase(dataDir string) error {
 		op := DefaultOption().
 			WithDbName(s.Options.GetDefaultDbName()).
 			WithDbRootPath(dataDir).
-			WithCorruptionChecker(s.Options.CorruptionCheck).WithDbRootPath(s.Options.Dir)
+			WithCorruptionChecker(s.Options.CorruptionCheck).WithDbRootPath(s.Options.Dir).
+			WithQuota(s.Options.GetDbQuota()).
+			WithBackupSchedule(s.Options.GetBackupSchedule(s.Options.GetDefaultDbName()))
 
 		db, err := OpenDb(op, s.Logger)
 		if err != nil {
@@ -412,7 +418,9 @@ func (s *ImmuServer) loadUserDatabases(dataDir string) error {
 		dbname := pathparts[len(pathparts)-1]
 
 		op := DefaultOption().WithDbName(dbname).WithDbRootPath(dataDir).
-			WithCorruptionChecker(s.Options.CorruptionCheck).WithDbRootPath(s.Options.Dir)
+			WithCorruptionChecker(s.Options.CorruptionCheck).WithDbRootPath(s.Options.Dir).
+			WithQuota(s.Options.GetDbQuota()).
+			WithBackupSchedule(s.Options.GetBackupSchedule(dbname))
 
 		db, err := OpenDb(op, s.Logger)
 		if err != nil {
@@ -437,13 +445,36 @@ func (s *ImmuServer) Stop() error {
 	defer func() { s.quit <- struct{}{} }()
 
 	if !s.Options.usingCustomListener {
-		s.GrpcServer.Stop()
+		s.stopGrpcServer()
 		defer func() { s.GrpcServer = nil }()
 	}
 
 	return s.CloseDatabases()
 }
 
+// stopGrpcServer stops accepting new connections and waits for the pending requests to complete,
+// forcing them to terminate once Options.ShutdownTimeout has elapsed
+func (s *ImmuServer) stopGrpcServer() {
+	if s.Options.ShutdownTimeout <= 0 {
+		s.GrpcServer.Stop()
+		return
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		s.GrpcServer.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(s.Options.ShutdownTimeout):
+		s.Logger.Warningf("Pending requests not completed within %s, forcing shutdown", s.Options.ShutdownTimeout)
+		s.GrpcServer.Stop()
+		<-stopped
+	}
+}
+
 //CloseDatabases closes all opened databases including the consinstency checker
 func (s *ImmuServer) CloseDatabases() error {
 	s.stopCorruptionChecker()
@@ -708,7 +739,7 @@ func (s *ImmuServer) Scan(ctx context.Context, opts *schema.ScanOptions) (*schem
 	if err != nil {
 		return nil, err
 	}
-	return s.dbList.GetByIndex(ind).Scan(opts)
+	return s.dbList.GetByIndex(ind).Scan(ctx, opts)
 }
 
 // Count ...
@@ -780,7 +811,7 @@ func (s *ImmuServer) History(ctx context.Context, options *schema.HistoryOptions
 	if err != nil {
 		return nil, err
 	}
-	return s.dbList.GetByIndex(ind).History(options)
+	return s.dbList.GetByIndex(ind).History(ctx, options)
 }
 
 // Health ...
@@ -830,7 +861,7 @@ func (s *ImmuServer) ZScan(ctx context.Context, opts *schema.ZScanOptions) (*sch
 	if err != nil {
 		return nil, err
 	}
-	return s.dbList.GetByIndex(ind).ZScan(opts)
+	return s.dbList.GetByIndex(ind).ZScan(ctx, opts)
 }
 
 // SafeZAdd ...
@@ -850,7 +881,7 @@ func (s *ImmuServer) IScan(ctx context.Context, opts *schema.IScanOptions) (*sch
 	if err != nil {
 		return nil, err
 	}
-	return s.dbList.GetByIndex(ind).IScan(opts)
+	return s.dbList.GetByIndex(ind).IScan(ctx, opts)
 }
 
 // Dump ...
@@ -899,12 +930,13 @@ func (s *ImmuServer) Dump(in *empty.Empty, stream schema.ImmuService_DumpServer)
 //}
 
 func (s *ImmuServer) installShutdownHandler() {
-	c := make(chan os.Signal)
+	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
 
 	go func() {
-		<-c
-		s.Logger.Infof("Caught SIGTERM")
+		sig := <-c
+		signal.Stop(c)
+		s.Logger.Infof("Caught %s, shutting down", sig)
 		if err := s.Stop(); err != nil {
 			s.Logger.Errorf("Shutdown error: %v", err)
 		}
@@ -1009,7 +1041,9 @@ func (s *ImmuServer) CreateDatabase(ctx context.Context, newdb *schema.Database)
 		WithDbName(newdb.Databasename).
 		WithDbRootPath(dataDir).
 		WithCorruptionChecker(s.Options.CorruptionCheck).
-		WithInMemoryStore(s.Options.GetInMemoryStore()).WithDbRootPath(s.Options.Dir)
+		WithInMemoryStore(s.Options.GetInMemoryStore()).WithDbRootPath(s.Options.Dir).
+		WithQuota(s.Options.GetDbQuota()).
+		WithBackupSchedule(s.Options.GetBackupSchedule(newdb.Databasename))
 
 	db, err := NewDb(op, s.Logger)
 	if err != nil {
@@ -1109,7 +1143,7 @@ func (s *ImmuServer) ListUsers(ctx context.Context, req *empty.Empty) (*schema.U
 		}
 	}
 
-	itemList, err := s.sysDb.Scan(&schema.ScanOptions{
+	itemList, err := s.sysDb.Scan(ctx, &schema.ScanOptions{
 		Prefix: []byte{sysstore.KeyPrefixUser},
 	})
 	if err != nil {
@@ -1617,7 +1651,7 @@ func (s *ImmuServer) mandatoryAuth() bool {
 	}
 	if s.sysDb != nil {
 		//check if there is only sysadmin on systemdb and no other user
-		itemList, err := s.sysDb.Scan(&schema.ScanOptions{
+		itemList, err := s.sysDb.Scan(context.Background(), &schema.ScanOptions{
 			Prefix: []byte{sysstore.KeyPrefixUser},
 		})
 		if err != nil {
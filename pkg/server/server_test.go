@@ -23,6 +23,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"log"
+	"net"
 	"os"
 	"path"
 	"path/filepath"
@@ -30,6 +31,7 @@ import (
 	"testing"
 	"time"
 
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
@@ -957,6 +959,20 @@ func testServerScanError(ctx context.Context, s *ImmuServer, t *testing.T) {
 	}
 }
 
+func testServerScanCanceled(ctx context.Context, s *ImmuServer, t *testing.T) {
+	cctx, cancel := context.WithCancel(ctx)
+	cancel()
+
+	_, err := s.Scan(cctx, &schema.ScanOptions{Prefix: kv[0].Key})
+	require.Equal(t, codes.Canceled, status.Code(err))
+	_, err = s.ZScan(cctx, &schema.ZScanOptions{Set: kv[0].Value})
+	require.Equal(t, codes.Canceled, status.Code(err))
+	_, err = s.IScan(cctx, &schema.IScanOptions{PageNumber: 1, PageSize: 1})
+	require.Equal(t, codes.Canceled, status.Code(err))
+	_, err = s.History(cctx, &schema.HistoryOptions{Key: kv[0].Key})
+	require.Equal(t, codes.Canceled, status.Code(err))
+}
+
 func testServerSafeReference(ctx context.Context, s *ImmuServer, t *testing.T) {
 	it, err := s.SafeSet(ctx, &schema.SafeSetOptions{
 		Kv: kv[0],
@@ -1113,6 +1129,7 @@ func TestServerDbOperations(t *testing.T) {
 	testServerZAddError(ctx, s, t)
 	testServerScan(ctx, s, t)
 	testServerScanError(ctx, s, t)
+	testServerScanCanceled(ctx, s, t)
 	testServerPrintTree(ctx, s, t)
 	testServerPrintTreeError(ctx, s, t)
 	testServerSafeReference(ctx, s, t)
@@ -1764,3 +1781,78 @@ func TestServerLoginAttempWithEmptyPassword(t *testing.T) {
 	require.Error(t, err)
 	require.Contains(t, err.Error(), "invalid user name or password")
 }
+
+// startSlowServer serves, on a local tcp listener, a server whose every method blocks until release is closed.
+// It returns a connection to the server and a channel receiving a value once a call is in flight.
+func startSlowServer(t *testing.T, s *ImmuServer, release chan struct{}) (*grpc.ClientConn, chan struct{}) {
+	inFlight := make(chan struct{}, 1)
+	s.GrpcServer = grpc.NewServer(grpc.UnknownServiceHandler(func(srv interface{}, stream grpc.ServerStream) error {
+		inFlight <- struct{}{}
+		<-release
+		return stream.SendMsg(&empty.Empty{})
+	}))
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go s.GrpcServer.Serve(lis)
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure())
+	require.NoError(t, err)
+	return conn, inFlight
+}
+
+func TestServerStopWaitsForPendingRequests(t *testing.T) {
+	s := DefaultServer()
+	s.WithOptions(DefaultOptions().WithCorruptionCheck(false).WithShutdownTimeout(10 * time.Second))
+	go func() { <-s.quit }()
+
+	release := make(chan struct{})
+	conn, inFlight := startSlowServer(t, s, release)
+	defer conn.Close()
+
+	callErr := make(chan error, 1)
+	go func() {
+		callErr <- conn.Invoke(context.Background(), "/test.Slow/Call", &empty.Empty{}, &empty.Empty{})
+	}()
+	<-inFlight
+
+	stopped := make(chan error, 1)
+	go func() { stopped <- s.Stop() }()
+
+	select {
+	case <-stopped:
+		t.Fatal("Stop returned while a request was pending")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(release)
+	require.NoError(t, <-callErr)
+	require.NoError(t, <-stopped)
+}
+
+func TestServerStopForcesPendingRequestsAfterTimeout(t *testing.T) {
+	timeout := 200 * time.Millisecond
+
+	s := DefaultServer()
+	s.WithOptions(DefaultOptions().WithCorruptionCheck(false).WithShutdownTimeout(timeout))
+	go func() { <-s.quit }()
+
+	release := make(chan struct{})
+	defer close(release)
+	conn, inFlight := startSlowServer(t, s, release)
+	defer conn.Close()
+
+	callErr := make(chan error, 1)
+	go func() {
+		callErr <- conn.Invoke(context.Background(), "/test.Slow/Call", &empty.Empty{}, &empty.Empty{})
+	}()
+	<-inFlight
+
+	start := time.Now()
+	require.NoError(t, s.Stop())
+	require.True(t, time.Since(start) >= timeout)
+
+	err := <-callErr
+	require.Error(t, err)
+	require.Equal(t, codes.Unavailable, status.Code(err))
+}
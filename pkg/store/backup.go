@@ -0,0 +1,307 @@
+/*
+Copyright 2019-2020 vChain, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dgraph-io/badger/v2/pb"
+	"github.com/golang/protobuf/proto"
+)
+
+// ErrInvalidBackupSchedule is returned when a backup schedule has no target directory or no interval
+var ErrInvalidBackupSchedule = errors.New("backup schedule requires a directory and a positive interval")
+
+const (
+	backupFileExt     = ".bkp"
+	backupManifestExt = ".json"
+	backupTimeLayout  = "20060102T150405.000000000"
+)
+
+// BackupRetention defines which archives are kept after each backup.
+// The most recent archive is never pruned. Zero values disable the corresponding rule.
+type BackupRetention struct {
+	KeepLast int
+	MaxAge   time.Duration
+}
+
+// BackupSchedule defines where and how often a store is backed up
+type BackupSchedule struct {
+	Dir       string
+	Interval  time.Duration
+	Retention BackupRetention
+}
+
+// BackupManifest records the provenance of an archive: the tree state the dump is consistent with.
+// It is written as a JSON file next to the archive.
+type BackupManifest struct {
+	File    string    `json:"file"`
+	Time    time.Time `json:"time"`
+	Index   uint64    `json:"index"`
+	Root    []byte    `json:"root"`
+	Entries uint64    `json:"entries"`
+}
+
+// BackupScheduler periodically dumps a store to a directory.
+// Archives use the same format as the dump written by immuadmin: a sequence of
+// little endian uint32 length prefixed, protobuf encoded key values.
+type BackupScheduler struct {
+	store    *Store
+	schedule BackupSchedule
+
+	mu      sync.Mutex
+	last    *BackupManifest
+	lastErr error
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	stopped  chan struct{}
+}
+
+// ScheduleBackups starts backing up the store every schedule.Interval.
+// Backups are full online dumps, streamed from a snapshot: reads and writes are not blocked while they run.
+// The scheduler stops when Stop is called or the store is closed.
+func (t *Store) ScheduleBackups(schedule BackupSchedule) (*BackupScheduler, error) {
+	if schedule.Dir == "" || schedule.Interval <= 0 {
+		return nil, ErrInvalidBackupSchedule
+	}
+	if err := os.MkdirAll(schedule.Dir, 0755); err != nil {
+		return nil, err
+	}
+
+	s := &BackupScheduler{
+		store:    t,
+		schedule: schedule,
+		stop:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+
+	t.bg.Add(1)
+	go s.run()
+
+	return s, nil
+}
+
+func (s *BackupScheduler) run() {
+	defer s.store.bg.Done()
+	defer close(s.stopped)
+
+	ticker := time.NewTicker(s.schedule.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if _, err := s.Backup(); err != nil {
+				s.store.log.Errorf("scheduled backup to %s failed: %v", s.schedule.Dir, err)
+			}
+		case <-s.stop:
+			return
+		case <-s.store.done:
+			return
+		}
+	}
+}
+
+// Backup takes a backup immediately and prunes the archives according to the retention policy.
+// It returns a nil manifest when the store is empty.
+func (s *BackupScheduler) Backup() (*BackupManifest, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, err := s.store.backupTo(s.schedule.Dir, time.Now())
+	if err == nil && m != nil {
+		s.last = m
+		err = pruneBackups(s.schedule.Dir, s.schedule.Retention, m.Time)
+	}
+	s.lastErr = err
+
+	return m, err
+}
+
+// Last returns the manifest of the last successful backup and the error of the last attempt, if any
+func (s *BackupScheduler) Last() (*BackupManifest, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.last, s.lastErr
+}
+
+// Stop stops the scheduler, waiting for a running backup to complete
+func (s *BackupScheduler) Stop() {
+	s.stopOnce.Do(func() { close(s.stop) })
+	<-s.stopped
+}
+
+// backupTo dumps the store into a new archive within dir and writes its manifest
+func (t *Store) backupTo(dir string, now time.Time) (*BackupManifest, error) {
+	name := "immudb_" + now.UTC().Format(backupTimeLayout) + backupFileExt
+	path := filepath.Join(dir, name)
+	tmp := path + ".tmp"
+
+	f, err := os.Create(tmp)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp)
+
+	m := &BackupManifest{File: name, Time: now}
+
+	kvChan := make(chan *pb.KVList)
+	done := make(chan error, 1)
+	go func() {
+		root, err := t.dump(kvChan)
+		if err == nil {
+			m.Index = root.GetIndex()
+			m.Root = root.GetRoot()
+		}
+		done <- err
+	}()
+
+	var werr error
+	w := bufio.NewWriter(f)
+	for list := range kvChan {
+		for _, kv := range list.Kv {
+			if werr == nil {
+				werr = writeBackupKV(w, kv)
+				m.Entries++
+			}
+		}
+	}
+	if err := <-done; err != nil {
+		f.Close()
+		return nil, err
+	}
+	if werr == nil {
+		werr = w.Flush()
+	}
+	if werr == nil {
+		werr = f.Sync()
+	}
+	if err := f.Close(); werr == nil {
+		werr = err
+	}
+	if werr != nil {
+		return nil, werr
+	}
+
+	if m.Entries == 0 {
+		return nil, nil
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		return nil, err
+	}
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(path+backupManifestExt, data, 0644); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func writeBackupKV(w *bufio.Writer, kv *pb.KV) error {
+	data, err := proto.Marshal(kv)
+	if err != nil {
+		return err
+	}
+	var size [4]byte
+	binary.LittleEndian.PutUint32(size[:], uint32(len(data)))
+	if _, err := w.Write(size[:]); err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// ListBackups returns the manifests of the archives found in dir, oldest first
+func ListBackups(dir string) ([]*BackupManifest, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifests []*BackupManifest
+	for _, fi := range files {
+		if fi.IsDir() || !strings.HasSuffix(fi.Name(), backupFileExt+backupManifestExt) {
+			continue
+		}
+		data, err := ioutil.ReadFile(filepath.Join(dir, fi.Name()))
+		if os.IsNotExist(err) {
+			// pruned while listing
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		m := &BackupManifest{}
+		if err := json.Unmarshal(data, m); err != nil {
+			return nil, err
+		}
+		manifests = append(manifests, m)
+	}
+
+	sort.Slice(manifests, func(i, j int) bool { return manifests[i].Time.Before(manifests[j].Time) })
+
+	return manifests, nil
+}
+
+// pruneBackups removes the archives, and their manifests, falling outside the retention policy
+func pruneBackups(dir string, retention BackupRetention, now time.Time) error {
+	if retention.KeepLast <= 0 && retention.MaxAge <= 0 {
+		return nil
+	}
+
+	manifests, err := ListBackups(dir)
+	if err != nil {
+		return err
+	}
+
+	for i, m := range manifests {
+		newer := len(manifests) - 1 - i
+		if newer == 0 {
+			break
+		}
+		expired := retention.MaxAge > 0 && now.Sub(m.Time) > retention.MaxAge
+		exceeding := retention.KeepLast > 0 && newer >= retention.KeepLast
+		if !expired && !exceeding {
+			continue
+		}
+		path := filepath.Join(dir, filepath.Base(m.File))
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if err := os.Remove(path + backupManifestExt); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
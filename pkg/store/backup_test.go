@@ -0,0 +1,227 @@
+/*
+Copyright 2019-2020 vChain, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/dgraph-io/badger/v2/pb"
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readBackup(t *testing.T, path string) []*pb.KV {
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+
+	var kvs []*pb.KV
+	for len(data) > 0 {
+		require.True(t, len(data) >= 4)
+		size := binary.LittleEndian.Uint32(data)
+		kv := &pb.KV{}
+		require.NoError(t, proto.Unmarshal(data[4:4+size], kv))
+		kvs = append(kvs, kv)
+		data = data[4+size:]
+	}
+	return kvs
+}
+
+func TestBackup(t *testing.T) {
+	st, closer := makeStore()
+	defer closer()
+
+	dir, err := ioutil.TempDir("", "immudb_backup")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	_, err = st.ScheduleBackups(BackupSchedule{})
+	assert.Equal(t, ErrInvalidBackupSchedule, err)
+
+	s, err := st.ScheduleBackups(BackupSchedule{Dir: dir, Interval: time.Hour})
+	require.NoError(t, err)
+	defer s.Stop()
+
+	m, err := s.Backup()
+	assert.NoError(t, err)
+	assert.Nil(t, m)
+
+	for n := 0; n < 32; n++ {
+		key := []byte(strconv.Itoa(n))
+		_, err := st.Set(schema.KeyValue{Key: key, Value: key})
+		require.NoError(t, err)
+	}
+	st.tree.WaitUntil(31)
+
+	m, err = s.Backup()
+	require.NoError(t, err)
+	require.NotNil(t, m)
+
+	root, err := st.CurrentRoot()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(31), m.Index)
+	assert.Equal(t, root.GetRoot(), m.Root)
+
+	last, err := s.Last()
+	assert.NoError(t, err)
+	assert.Equal(t, m, last)
+
+	manifests, err := ListBackups(dir)
+	require.NoError(t, err)
+	require.Len(t, manifests, 1)
+	assert.Equal(t, m.File, manifests[0].File)
+	assert.Equal(t, m.Index, manifests[0].Index)
+	assert.Equal(t, m.Root, manifests[0].Root)
+
+	kvs := readBackup(t, filepath.Join(dir, m.File))
+	assert.Equal(t, m.Entries, uint64(len(kvs)))
+
+	// restoring the archive yields the root recorded in the manifest
+	st2, closer2 := makeStore()
+	defer closer2()
+
+	kvChan := make(chan *pb.KVList, 1)
+	kvChan <- &pb.KVList{Kv: kvs}
+	_, err = st2.Restore(kvChan)
+	require.NoError(t, err)
+
+	root2, err := st2.CurrentRoot()
+	require.NoError(t, err)
+	assert.Equal(t, m.Index, root2.GetIndex())
+	assert.Equal(t, m.Root, root2.GetRoot())
+}
+
+func TestBackupRetention(t *testing.T) {
+	st, closer := makeStore()
+	defer closer()
+
+	dir, err := ioutil.TempDir("", "immudb_backup")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	_, err = st.Set(schema.KeyValue{Key: []byte(`k`), Value: []byte(`v`)})
+	require.NoError(t, err)
+	st.tree.WaitUntil(0)
+
+	now := time.Now()
+	for i := 5; i >= 0; i-- {
+		_, err := st.backupTo(dir, now.Add(-time.Duration(i)*time.Hour))
+		require.NoError(t, err)
+	}
+
+	assert.NoError(t, pruneBackups(dir, BackupRetention{KeepLast: 4}, now))
+	manifests, err := ListBackups(dir)
+	require.NoError(t, err)
+	require.Len(t, manifests, 4)
+	assert.True(t, manifests[0].Time.Equal(now.Add(-3*time.Hour)))
+
+	assert.NoError(t, pruneBackups(dir, BackupRetention{MaxAge: 90 * time.Minute}, now))
+	manifests, err = ListBackups(dir)
+	require.NoError(t, err)
+	require.Len(t, manifests, 2)
+	assert.True(t, manifests[0].Time.Equal(now.Add(-time.Hour)))
+
+	// the most recent archive is always kept
+	assert.NoError(t, pruneBackups(dir, BackupRetention{MaxAge: time.Nanosecond}, now.Add(time.Hour)))
+	manifests, err = ListBackups(dir)
+	require.NoError(t, err)
+	require.Len(t, manifests, 1)
+	assert.True(t, manifests[0].Time.Equal(now))
+
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, files, 2)
+}
+
+func TestBackupSchedule(t *testing.T) {
+	st, closer := makeStore()
+
+	dir, err := ioutil.TempDir("", "immudb_backup")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	_, err = st.Set(schema.KeyValue{Key: []byte(`k`), Value: []byte(`v`)})
+	require.NoError(t, err)
+
+	_, err = st.ScheduleBackups(BackupSchedule{
+		Dir:       dir,
+		Interval:  10 * time.Millisecond,
+		Retention: BackupRetention{KeepLast: 2},
+	})
+	require.NoError(t, err)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		manifests, err := ListBackups(dir)
+		require.NoError(t, err)
+		if len(manifests) == 2 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// closing the store stops the scheduler
+	closer()
+
+	manifests, err := ListBackups(dir)
+	require.NoError(t, err)
+	assert.Len(t, manifests, 2)
+}
+
+func TestDumpWaitsForBatchBoundary(t *testing.T) {
+	st, closer := makeStore()
+	defer closer()
+
+	// the tree gets only the first entry of a batch committed at the ts of the second one
+	lease, _ := st.tree.Lease(2, 0)
+	entries := st.tree.BatchAt(lease, &schema.KVList{KVs: []*schema.KeyValue{
+		{Key: []byte(`a`), Value: []byte(`1`)},
+		{Key: []byte(`b`), Value: []byte(`2`)},
+	}})
+	st.tree.Commit(entries[0])
+	st.tree.WaitUntil(0)
+
+	dumped := make(chan *schema.Root, 1)
+	go func() {
+		kvChan := make(chan *pb.KVList)
+		go func() {
+			for range kvChan {
+			}
+		}()
+		root, err := st.dump(kvChan)
+		assert.NoError(t, err)
+		dumped <- root
+	}()
+
+	select {
+	case <-dumped:
+		t.Fatal("dump pinned the tree within a batch")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	st.tree.Commit(entries[1])
+
+	root := <-dumped
+	assert.Equal(t, uint64(1), root.GetIndex())
+}
@@ -15,16 +15,36 @@ func (t *Store) SetBatch(list schema.KVList, options ...WriteOption) (index *sch
 	if err = list.Validate(); err != nil {
 		return nil, err
 	}
-	opts := makeWriteOptions(options...)
-	txn := t.db.NewTransactionAt(math.MaxUint64, true)
+	for _, kv := range list.KVs {
+		if err = checkKey(kv.Key); err != nil {
+			return nil, err
+		}
+	}
+	return t.commitBatch(t.db.NewTransactionAt(math.MaxUint64, true), list, makeWriteOptions(options...), false)
+}
+
+// commitBatch writes list using txn and commits it, txn is always discarded.
+// Keys of list must be already validated.
+// When settle is true the commit waits for every entry leased before the batch to be either committed or discarded,
+// so that badger detects the conflicts with writes having a lower index but committed after the txn read timestamp.
+func (t *Store) commitBatch(txn *badger.Txn, list schema.KVList, opts *WriteOptions, settle bool) (index *schema.Index, err error) {
 	defer txn.Discard()
 
-	tsEntries := t.tree.NewBatch(&list)
+	var size int64
+	for _, kv := range list.KVs {
+		size += int64(len(kv.Key) + len(kv.Value))
+	}
+	lease, err := t.lease(uint64(len(list.KVs)), size)
+	if err != nil {
+		return nil, err
+	}
+	tsEntries := t.tree.BatchAt(lease, &list)
+
+	if first := tsEntries[0].ts; settle && first > 1 {
+		t.tree.WaitUntil(first - 2)
+	}
 
 	for i, kv := range list.KVs {
-		if err = checkKey(kv.Key); err != nil {
-			return nil, err
-		}
 		if err = txn.SetEntry(&badger.Entry{
 			Key:   kv.Key,
 			Value: WrapValueWithTS(kv.Value, tsEntries[i].ts),
@@ -94,8 +114,19 @@ func (t *Store) ExecAllOps(ops *schema.Ops, options ...WriteOption) (index *sche
 	// we build a map in which we store sha256 sum as key and the index as value
 	kmap := make(map[[32]byte]uint64)
 
+	// sorted set and reference values are not known yet, so only key values contribute to the size
+	var size int64
+	for _, op := range ops.Operations {
+		if kvs := op.GetKVs(); kvs != nil {
+			size += int64(len(kvs.Key) + len(kvs.Value))
+		}
+	}
 	// in order to get a monotone sequence of ts here is obtained a ts range
-	tsRange := t.tree.NewOpsTsRange(ops)
+	lease, err := t.lease(uint64(len(ops.Operations)), size)
+	if err != nil {
+		return nil, err
+	}
+	tsRange := lease - uint64(len(ops.Operations))
 	for i, op := range ops.Operations {
 		ats := tsRange + uint64(i) + 1
 		switch x := op.Operation.(type) {
@@ -103,9 +134,10 @@ func (t *Store) ExecAllOps(ops *schema.Ops, options ...WriteOption) (index *sche
 			kvList.KVs = append(kvList.KVs, x.KVs)
 			h := api.Digest(ats-1, x.KVs.Key, x.KVs.Value)
 			entry := &treeStoreEntry{
-				ts: ats,
-				h:  &h,
-				r:  &x.KVs.Key,
+				ts:       ats,
+				h:        &h,
+				r:        &x.KVs.Key,
+				commitTs: lease,
 			}
 
 			kmap[sha256.Sum256(x.KVs.Key)] = entry.Index()
@@ -133,9 +165,10 @@ func (t *Store) ExecAllOps(ops *schema.Ops, options ...WriteOption) (index *sche
 			kvList.KVs = append(kvList.KVs, kv)
 			h := api.Digest(ats-1, kv.Key, kv.Value)
 			entry := &treeStoreEntry{
-				ts: ats,
-				h:  &h,
-				r:  &kv.Key,
+				ts:       ats,
+				h:        &h,
+				r:        &kv.Key,
+				commitTs: lease,
 			}
 			tsEntriesKv = append(tsEntriesKv, entry)
 		case *schema.Op_ROpts:
@@ -161,9 +194,10 @@ func (t *Store) ExecAllOps(ops *schema.Ops, options ...WriteOption) (index *sche
 			kvList.KVs = append(kvList.KVs, kv)
 			h := api.Digest(ats-1, kv.Key, kv.Value)
 			entry := &treeStoreEntry{
-				ts: ats,
-				h:  &h,
-				r:  &kv.Key,
+				ts:       ats,
+				h:        &h,
+				r:        &kv.Key,
+				commitTs: lease,
 			}
 			tsEntriesKv = append(tsEntriesKv, entry)
 
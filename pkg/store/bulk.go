@@ -0,0 +1,253 @@
+/*
+Copyright 2019-2020 vChain, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"math"
+	"strconv"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/dgraph-io/badger/v2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// BulkFormat is the encoding used by ImportFrom and ExportTo
+type BulkFormat int
+
+const (
+	// JSONLines encodes one {"key","value","index"} object per line, keys and values are base64 encoded
+	JSONLines BulkFormat = iota
+	// CSV encodes one key,value,index record per line, keys and values are base64 encoded
+	CSV
+)
+
+// ErrInvalidBulkFormat is returned when an unknown BulkFormat is requested
+var ErrInvalidBulkFormat = status.New(codes.InvalidArgument, "invalid bulk format").Err()
+
+const defaultImportBatchSize = 1000
+
+// exportProgressInterval is the number of exported entries between two ExportSpec.Progress calls
+const exportProgressInterval = 1000
+
+// bulkRecord is a single imported or exported entry.
+// Index is informative only: it's filled on export and ignored on import, since the store assigns new indexes.
+type bulkRecord struct {
+	Key   []byte `json:"key"`
+	Value []byte `json:"value"`
+	Index uint64 `json:"index"`
+}
+
+// ImportSpec configures ImportFrom
+type ImportSpec struct {
+	Format BulkFormat
+	// BatchSize is the maximum number of entries committed at once, defaults to 1000
+	BatchSize int
+	// Progress, if set, is called with the number of imported entries after each committed batch
+	Progress func(imported uint64)
+}
+
+// ExportSpec selects the entries written by ExportTo
+type ExportSpec struct {
+	Format  BulkFormat
+	Prefix  []byte
+	Reverse bool
+	// Progress, if set, is called with the number of exported entries every 1000 entries and once done
+	Progress func(exported uint64)
+}
+
+// ImportFrom reads the records encoded in spec.Format from r and commits them using batches of at most spec.BatchSize entries.
+// A batch is committed early when it would contain the same key twice, so that the history of each key is preserved.
+// It returns the number of imported entries, on error the entries of already committed batches are kept.
+func (t *Store) ImportFrom(r io.Reader, spec ImportSpec) (imported uint64, err error) {
+	next, err := newBulkDecoder(r, spec.Format)
+	if err != nil {
+		return 0, err
+	}
+	batchSize := spec.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultImportBatchSize
+	}
+
+	kvs := make([]*schema.KeyValue, 0, batchSize)
+	keys := make(map[string]struct{}, batchSize)
+
+	commit := func() error {
+		if len(kvs) == 0 {
+			return nil
+		}
+		if _, err := t.SetBatch(schema.KVList{KVs: kvs}); err != nil {
+			return err
+		}
+		imported += uint64(len(kvs))
+		t.log.Debugf("Imported %d entries", imported)
+		if spec.Progress != nil {
+			spec.Progress(imported)
+		}
+		kvs = make([]*schema.KeyValue, 0, batchSize)
+		keys = make(map[string]struct{}, batchSize)
+		return nil
+	}
+
+	for record := 1; ; record++ {
+		rec, err := next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return imported, status.Newf(codes.InvalidArgument, "invalid record %d: %v", record, err).Err()
+		}
+		if err = checkKey(rec.Key); err != nil {
+			return imported, status.Newf(codes.InvalidArgument, "invalid record %d: %v", record, err).Err()
+		}
+
+		if _, exists := keys[string(rec.Key)]; exists || len(kvs) == batchSize {
+			if err = commit(); err != nil {
+				return imported, err
+			}
+		}
+		kvs = append(kvs, &schema.KeyValue{Key: rec.Key, Value: rec.Value})
+		keys[string(rec.Key)] = struct{}{}
+	}
+
+	return imported, commit()
+}
+
+// ExportTo writes the latest value of every key matching spec to w, encoded in spec.Format.
+// Sorted set and reference entries are not exported.
+// It returns the number of exported entries.
+func (t *Store) ExportTo(w io.Writer, spec ExportSpec) (exported uint64, err error) {
+	if isReservedKey(spec.Prefix) {
+		return 0, ErrInvalidKeyPrefix
+	}
+	bw := bufio.NewWriter(w)
+	write, flush, err := newBulkEncoder(bw, spec.Format)
+	if err != nil {
+		return 0, err
+	}
+
+	txn := t.db.NewTransactionAt(math.MaxUint64, false)
+	defer txn.Discard()
+
+	it := txn.NewIterator(badger.IteratorOptions{
+		PrefetchValues: true,
+		Prefix:         spec.Prefix,
+		Reverse:        spec.Reverse,
+	})
+	defer it.Close()
+
+	seekKey := spec.Prefix
+	if spec.Reverse {
+		seekKey = append(append([]byte{}, spec.Prefix...), 0xFF)
+	}
+
+	for it.Seek(seekKey); it.Valid(); it.Next() {
+		i := it.Item()
+		if isReservedKey(i.Key()) || i.UserMeta()&bitReferenceEntry == bitReferenceEntry {
+			continue
+		}
+		item, err := itemToSchema(nil, i)
+		if err != nil {
+			return exported, err
+		}
+		if err = write(&bulkRecord{Key: item.Key, Value: item.Value, Index: item.Index}); err != nil {
+			return exported, err
+		}
+		exported++
+		if spec.Progress != nil && exported%exportProgressInterval == 0 {
+			spec.Progress(exported)
+		}
+	}
+
+	if err = flush(); err != nil {
+		return exported, err
+	}
+	if err = bw.Flush(); err != nil {
+		return exported, err
+	}
+	if spec.Progress != nil && exported%exportProgressInterval != 0 {
+		spec.Progress(exported)
+	}
+	return exported, nil
+}
+
+func newBulkDecoder(r io.Reader, format BulkFormat) (func() (*bulkRecord, error), error) {
+	switch format {
+	case JSONLines:
+		dec := json.NewDecoder(r)
+		return func() (*bulkRecord, error) {
+			rec := &bulkRecord{}
+			if err := dec.Decode(rec); err != nil {
+				return nil, err
+			}
+			return rec, nil
+		}, nil
+	case CSV:
+		cr := csv.NewReader(r)
+		cr.FieldsPerRecord = -1
+		return func() (*bulkRecord, error) {
+			fields, err := cr.Read()
+			if err != nil {
+				return nil, err
+			}
+			if len(fields) < 2 {
+				return nil, csv.ErrFieldCount
+			}
+			key, err := base64.StdEncoding.DecodeString(fields[0])
+			if err != nil {
+				return nil, err
+			}
+			value, err := base64.StdEncoding.DecodeString(fields[1])
+			if err != nil {
+				return nil, err
+			}
+			return &bulkRecord{Key: key, Value: value}, nil
+		}, nil
+	}
+	return nil, ErrInvalidBulkFormat
+}
+
+func newBulkEncoder(w io.Writer, format BulkFormat) (write func(*bulkRecord) error, flush func() error, err error) {
+	switch format {
+	case JSONLines:
+		enc := json.NewEncoder(w)
+		write = func(rec *bulkRecord) error {
+			return enc.Encode(rec)
+		}
+		return write, func() error { return nil }, nil
+	case CSV:
+		cw := csv.NewWriter(w)
+		write = func(rec *bulkRecord) error {
+			return cw.Write([]string{
+				base64.StdEncoding.EncodeToString(rec.Key),
+				base64.StdEncoding.EncodeToString(rec.Value),
+				strconv.FormatUint(rec.Index, 10),
+			})
+		}
+		flush = func() error {
+			cw.Flush()
+			return cw.Error()
+		}
+		return write, flush, nil
+	}
+	return nil, nil, ErrInvalidBulkFormat
+}
@@ -0,0 +1,142 @@
+/*
+Copyright 2019-2020 vChain, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImportFromCSV(t *testing.T) {
+	st, closer := makeStore()
+	defer closer()
+
+	// k1,v1 k2,v2 k1,v3 k3,v4
+	in := "azE=,djE=\nazI=,djI=\nazE=,djM=\nazM=,djQ=\n"
+	var progress []uint64
+	n, err := st.ImportFrom(strings.NewReader(in), ImportSpec{
+		Format:    CSV,
+		BatchSize: 2,
+		Progress:  func(imported uint64) { progress = append(progress, imported) },
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(4), n)
+	assert.Equal(t, []uint64{2, 4}, progress)
+
+	item, err := st.Get(schema.Key{Key: []byte(`k1`)})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte(`v3`), item.Value)
+
+	history, err := st.History(&schema.HistoryOptions{Key: []byte(`k1`)})
+	assert.NoError(t, err)
+	assert.Len(t, history.Items, 2)
+}
+
+func TestImportFromInvalidRecord(t *testing.T) {
+	st, closer := makeStore()
+	defer closer()
+
+	in := "azE=,djE=\nazI=\n"
+	n, err := st.ImportFrom(strings.NewReader(in), ImportSpec{Format: CSV, BatchSize: 10})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "record 2")
+	assert.Equal(t, uint64(0), n)
+
+	_, err = st.ImportFrom(strings.NewReader("azE=,not base64\n"), ImportSpec{Format: CSV})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "record 1")
+
+	_, err = st.ImportFrom(strings.NewReader("{}\n"), ImportSpec{Format: JSONLines})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "record 1")
+
+	_, err = st.ImportFrom(strings.NewReader(in), ImportSpec{Format: BulkFormat(99)})
+	assert.Equal(t, ErrInvalidBulkFormat, err)
+}
+
+func TestExportImportJSONLines(t *testing.T) {
+	st, closer := makeStore()
+	defer closer()
+
+	_, err := st.Set(schema.KeyValue{Key: []byte(`a1`), Value: []byte(`x`)})
+	assert.NoError(t, err)
+	_, err = st.Set(schema.KeyValue{Key: []byte(`a2`), Value: []byte{0, 1, 2}})
+	assert.NoError(t, err)
+	_, err = st.Set(schema.KeyValue{Key: []byte(`b1`), Value: []byte(`y`)})
+	assert.NoError(t, err)
+	_, err = st.ZAdd(schema.ZAddOptions{Set: []byte(`set`), Key: []byte(`a1`), Score: &schema.Score{Score: 1}})
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	n, err := st.ExportTo(&buf, ExportSpec{Format: JSONLines, Prefix: []byte(`a`)})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), n)
+
+	st2, closer2 := makeStore()
+	defer closer2()
+
+	n, err = st2.ImportFrom(&buf, ImportSpec{Format: JSONLines})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), n)
+
+	item, err := st2.Get(schema.Key{Key: []byte(`a2`)})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0, 1, 2}, item.Value)
+
+	_, err = st2.Get(schema.Key{Key: []byte(`b1`)})
+	assert.Equal(t, ErrKeyNotFound, err)
+}
+
+func TestExportToCSV(t *testing.T) {
+	st, closer := makeStore()
+	defer closer()
+
+	_, err := st.Set(schema.KeyValue{Key: []byte(`k1`), Value: []byte(`v1`)})
+	assert.NoError(t, err)
+	_, err = st.Set(schema.KeyValue{Key: []byte(`k2`), Value: []byte("v,2\r\n")})
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	var progress []uint64
+	n, err := st.ExportTo(&buf, ExportSpec{
+		Format:   CSV,
+		Reverse:  true,
+		Progress: func(exported uint64) { progress = append(progress, exported) },
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), n)
+	assert.Equal(t, []uint64{2}, progress)
+	assert.Equal(t, "azI=,diwyDQo=,1\nazE=,djE=,0\n", buf.String())
+
+	st2, closer2 := makeStore()
+	defer closer2()
+
+	n, err = st2.ImportFrom(&buf, ImportSpec{Format: CSV})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), n)
+
+	item, err := st2.Get(schema.Key{Key: []byte(`k2`)})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("v,2\r\n"), item.Value)
+
+	_, err = st.ExportTo(&buf, ExportSpec{Prefix: []byte{0}})
+	assert.Equal(t, ErrInvalidKeyPrefix, err)
+}
@@ -17,6 +17,8 @@ limitations under the License.
 package store
 
 import (
+	"context"
+
 	"github.com/dgraph-io/badger/v2"
 
 	"google.golang.org/grpc/codes"
@@ -38,8 +40,23 @@ var (
 	ErrIndexKeyMismatch      = status.New(codes.InvalidArgument, "mismatch between provided index and key").Err()
 	ErrZAddIndexMissing      = status.New(codes.InvalidArgument, "zAdd index not provided").Err()
 	ErrReferenceIndexMissing = status.New(codes.InvalidArgument, "reference index not provided").Err()
+	ErrTxReadConflict        = status.New(codes.Aborted, "keys read by the transaction have been modified").Err()
+	ErrCanceled              = status.New(codes.Canceled, "operation canceled").Err()
+	ErrDeadlineExceeded      = status.New(codes.DeadlineExceeded, "operation deadline exceeded").Err()
 )
 
+// contextErr returns the error matching the cancellation of ctx, or nil if ctx is still active
+func contextErr(ctx context.Context) error {
+	switch ctx.Err() {
+	case nil:
+		return nil
+	case context.DeadlineExceeded:
+		return ErrDeadlineExceeded
+	default:
+		return ErrCanceled
+	}
+}
+
 // fixme(leogr): review codes and fix/remove errors which do not make sense in this context, finally correct comments accordingly.
 var (
 	// ErrValueLogSize is returned when opt.ValueLogFileSize option is not within the valid
@@ -0,0 +1,134 @@
+/*
+Copyright 2019-2020 vChain, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// keyIDFilename is the name of the file, stored in plain text next to the data, recording the id of the encryption key
+const keyIDFilename = "KEYID"
+
+// ErrUnknownKeyID is returned when a KeyProvider cannot supply the key the store was encrypted with
+var ErrUnknownKeyID = status.New(codes.FailedPrecondition, "encryption key not available").Err()
+
+// validKeyLength reports whether n is the length of an AES-128, AES-192 or AES-256 key
+func validKeyLength(n int) bool {
+	return n == 16 || n == 24 || n == 32
+}
+
+// KeyProvider supplies the key used to encrypt the store at rest.
+// Keys must be 16, 24 or 32 bytes long, to select AES-128, AES-192 or AES-256.
+type KeyProvider interface {
+	// Key returns the key identified by id along with its id.
+	// id is empty when opening a store for the first time, the provider should then return its current key.
+	Key(id string) (keyID string, key []byte, err error)
+}
+
+// KeyProviderFunc adapts a function, eg. a call to an external KMS, to the KeyProvider interface
+type KeyProviderFunc func(id string) (string, []byte, error)
+
+// Key ...
+func (f KeyProviderFunc) Key(id string) (string, []byte, error) {
+	return f(id)
+}
+
+type staticKeyProvider struct {
+	id  string
+	key func() ([]byte, error)
+}
+
+func (p *staticKeyProvider) Key(id string) (string, []byte, error) {
+	if id != "" && id != p.id {
+		return "", nil, ErrUnknownKeyID
+	}
+	key, err := p.key()
+	if err != nil {
+		return "", nil, err
+	}
+	return p.id, key, nil
+}
+
+// NewStaticKeyProvider returns a KeyProvider always supplying the given key
+func NewStaticKeyProvider(id string, key []byte) KeyProvider {
+	return &staticKeyProvider{id: id, key: func() ([]byte, error) { return key, nil }}
+}
+
+// NewEnvKeyProvider returns a KeyProvider supplying the base64 encoded key held by the environment variable name
+func NewEnvKeyProvider(id string, name string) KeyProvider {
+	return &staticKeyProvider{id: id, key: func() ([]byte, error) {
+		v, ok := os.LookupEnv(name)
+		if !ok {
+			return nil, status.Newf(codes.FailedPrecondition, "encryption key variable %s not set", name).Err()
+		}
+		return base64.StdEncoding.DecodeString(strings.TrimSpace(v))
+	}}
+}
+
+// NewFileKeyProvider returns a KeyProvider supplying the raw key stored in the file at path.
+// The file must hold exactly the 16, 24 or 32 key bytes, not encoded and without a trailing newline,
+// eg. as written by head -c 32 /dev/urandom.
+func NewFileKeyProvider(id string, path string) KeyProvider {
+	return &staticKeyProvider{id: id, key: func() ([]byte, error) {
+		key, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if !validKeyLength(len(key)) {
+			return nil, status.Newf(codes.InvalidArgument, "encryption key file %s holds %d bytes, expected a raw key of 16, 24 or 32 bytes", path, len(key)).Err()
+		}
+		return key, nil
+	}}
+}
+
+// unlock asks provider the key for the store in dir.
+// recorded is false when the store has no key id recorded yet, see recordKeyID.
+// An empty dir stands for an in-memory store, which has no key id recorded.
+func unlock(provider KeyProvider, dir string) (keyID string, key []byte, recorded bool, err error) {
+	var recordedID []byte
+	if dir != "" {
+		recordedID, err = ioutil.ReadFile(filepath.Join(dir, keyIDFilename))
+		if err != nil && !os.IsNotExist(err) {
+			return "", nil, false, err
+		}
+	}
+
+	keyID, key, err = provider.Key(string(recordedID))
+	if err != nil {
+		return "", nil, false, err
+	}
+	if keyID == "" || (len(recordedID) > 0 && keyID != string(recordedID)) {
+		return "", nil, false, ErrUnknownKeyID
+	}
+	if !validKeyLength(len(key)) {
+		return "", nil, false, status.Newf(codes.InvalidArgument, "encryption key %s is %d bytes long, expected 16, 24 or 32 bytes", keyID, len(key)).Err()
+	}
+
+	return keyID, key, len(recordedID) > 0, nil
+}
+
+// recordKeyID persists the id of the key the store in dir is encrypted with
+func recordKeyID(dir string, keyID string) error {
+	return ioutil.WriteFile(filepath.Join(dir, keyIDFilename), []byte(keyID), 0644)
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2019-2020 vChain, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+func openEncrypted(dir string, provider KeyProvider) (*Store, error) {
+	return openStoreAt(dir, func(opts Options) Options { return opts.WithKeyProvider(provider) })
+}
+
+func TestOpenWithKeyProvider(t *testing.T) {
+	dir := tmpDir()
+	defer os.RemoveAll(dir)
+
+	key := []byte("0123456789abcdef0123456789abcdef")
+
+	st, err := openEncrypted(dir, NewStaticKeyProvider("k1", key))
+	assert.NoError(t, err)
+	assert.Equal(t, "k1", st.KeyID())
+	_, err = st.Set(schema.KeyValue{Key: []byte(`key`), Value: []byte(`val`)})
+	assert.NoError(t, err)
+	assert.NoError(t, st.Close())
+
+	keyID, err := ioutil.ReadFile(filepath.Join(dir, keyIDFilename))
+	assert.NoError(t, err)
+	assert.Equal(t, "k1", string(keyID))
+
+	_, err = openEncrypted(dir, NewStaticKeyProvider("k2", key))
+	assert.Equal(t, ErrUnknownKeyID, err)
+
+	var requested string
+	st, err = openEncrypted(dir, KeyProviderFunc(func(id string) (string, []byte, error) {
+		requested = id
+		return id, key, nil
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, "k1", requested)
+
+	item, err := st.Get(schema.Key{Key: []byte(`key`)})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte(`val`), item.Value)
+	assert.NoError(t, st.Close())
+}
+
+func TestEnvAndFileKeyProviders(t *testing.T) {
+	key := []byte("0123456789abcdef")
+
+	os.Setenv("IMMUDB_TEST_KEY", base64.StdEncoding.EncodeToString(key))
+	defer os.Unsetenv("IMMUDB_TEST_KEY")
+
+	id, k, err := NewEnvKeyProvider("env", "IMMUDB_TEST_KEY").Key("")
+	assert.NoError(t, err)
+	assert.Equal(t, "env", id)
+	assert.Equal(t, key, k)
+
+	_, _, err = NewEnvKeyProvider("env", "IMMUDB_TEST_MISSING_KEY").Key("")
+	assert.Error(t, err)
+
+	f, err := ioutil.TempFile("", "immu_key")
+	assert.NoError(t, err)
+	defer os.Remove(f.Name())
+	_, err = f.Write(key)
+	assert.NoError(t, err)
+	f.Close()
+
+	id, k, err = NewFileKeyProvider("file", f.Name()).Key("file")
+	assert.NoError(t, err)
+	assert.Equal(t, "file", id)
+	assert.Equal(t, key, k)
+
+	_, _, err = NewFileKeyProvider("file", f.Name()).Key("other")
+	assert.Equal(t, ErrUnknownKeyID, err)
+
+	// a trailing newline makes the key 17 bytes long
+	assert.NoError(t, ioutil.WriteFile(f.Name(), append(key, '\n'), 0600))
+	_, _, err = NewFileKeyProvider("file", f.Name()).Key("file")
+	assert.EqualError(t, err, "rpc error: code = InvalidArgument desc = encryption key file "+f.Name()+" holds 17 bytes, expected a raw key of 16, 24 or 32 bytes")
+}
+
+func TestOpenWithInvalidKey(t *testing.T) {
+	dir := tmpDir()
+	defer os.RemoveAll(dir)
+
+	_, err := openEncrypted(dir, NewStaticKeyProvider("k1", []byte("short")))
+	assert.EqualError(t, err, "rpc error: code = InvalidArgument desc = encryption key k1 is 5 bytes long, expected 16, 24 or 32 bytes")
+
+	_, err = os.Stat(filepath.Join(dir, keyIDFilename))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestOpenInMemoryWithKeyProvider(t *testing.T) {
+	wd, err := os.Getwd()
+	assert.NoError(t, err)
+	dir := tmpDir()
+	defer os.RemoveAll(dir)
+	assert.NoError(t, os.Chdir(dir))
+	defer os.Chdir(wd)
+
+	key := []byte("0123456789abcdef")
+	opts, badgerOpts := DefaultOptions("", logger.NewSimpleLoggerWithLevel("bm(immudb)", os.Stderr, logger.LogDebug))
+	opts = opts.WithKeyProvider(NewStaticKeyProvider("k1", key))
+	badgerOpts = badgerOpts.WithInMemory(true)
+
+	st, err := Open(opts, badgerOpts)
+	assert.NoError(t, err)
+	assert.Equal(t, "k1", st.KeyID())
+	assert.NoError(t, st.Close())
+
+	// no key id is recorded in the working directory, nor read from there
+	_, err = os.Stat(keyIDFilename)
+	assert.True(t, os.IsNotExist(err))
+
+	assert.NoError(t, ioutil.WriteFile(keyIDFilename, []byte("k2"), 0644))
+	st, err = Open(opts, badgerOpts)
+	assert.NoError(t, err)
+	assert.NoError(t, st.Close())
+}
@@ -26,7 +26,9 @@ import (
 
 // Options ...
 type Options struct {
-	log logger.Logger
+	log         logger.Logger
+	quota       Quota
+	keyProvider KeyProvider
 }
 
 // DefaultOptions ...
@@ -39,7 +41,19 @@ func DefaultOptions(path string, log logger.Logger) (Options, badger.Options) {
 	if runtime.GOOS == "windows" {
 		badgerOptions.Truncate = true
 	}
-	return Options{log}, badgerOptions
+	return Options{log: log}, badgerOptions
+}
+
+// WithKeyProvider enables encryption at rest using the key supplied by provider
+func (o Options) WithKeyProvider(provider KeyProvider) Options {
+	o.keyProvider = provider
+	return o
+}
+
+// WithQuota sets the storage quota enforced on writes
+func (o Options) WithQuota(quota Quota) Options {
+	o.quota = quota
+	return o
 }
 
 // WriteOptions ...
@@ -0,0 +1,86 @@
+/*
+Copyright 2019-2020 vChain, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"sync/atomic"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrQuotaExceeded is returned when a write would exceed the store quota
+var ErrQuotaExceeded = status.New(codes.ResourceExhausted, "storage quota exceeded").Err()
+
+// Quota bounds the storage footprint of a store. Zero limits are not enforced.
+type Quota struct {
+	// MaxBytes limits the on-disk size, as reported by DbSize
+	MaxBytes int64
+	// MaxEntries limits the number of entries, including sorted set and reference ones
+	MaxEntries uint64
+	// OnExceeded, if set, is called before rejecting a write that would exceed the quota.
+	// Returning true lets the write through, eg. to grant a grace period while the tenant is notified.
+	OnExceeded func(usage QuotaUsage) bool
+}
+
+// QuotaUsage reports the storage used by a store against its quota
+type QuotaUsage struct {
+	Bytes      int64
+	Entries    uint64
+	MaxBytes   int64
+	MaxEntries uint64
+}
+
+// QuotaUsage returns the storage currently used by the store
+func (t *Store) QuotaUsage() QuotaUsage {
+	lsm, vlog := t.DbSize()
+	return QuotaUsage{
+		Bytes:      lsm + vlog,
+		Entries:    atomic.LoadUint64(&t.tree.ts),
+		MaxBytes:   t.quota.MaxBytes,
+		MaxEntries: t.quota.MaxEntries,
+	}
+}
+
+// lease leases n entries, having approximately the given size, within the quota and returns the last leased ts.
+// The entries limit is enforced atomically with the lease, while the bytes limit is enforced on a best effort basis
+// since badger updates its size periodically.
+func (t *Store) lease(n uint64, size int64) (uint64, error) {
+	if t.quota.MaxBytes > 0 {
+		if usage := t.QuotaUsage(); usage.Bytes+size > usage.MaxBytes && !t.allowOverQuota(usage) {
+			return 0, ErrQuotaExceeded
+		}
+	}
+
+	if ts, ok := t.tree.Lease(n, t.quota.MaxEntries); ok {
+		return ts, nil
+	}
+	if !t.allowOverQuota(t.QuotaUsage()) {
+		return 0, ErrQuotaExceeded
+	}
+	ts, _ := t.tree.Lease(n, 0)
+	return ts, nil
+}
+
+// allowOverQuota tells whether the grace callback lets a write exceeding the quota through
+func (t *Store) allowOverQuota(usage QuotaUsage) bool {
+	if t.quota.OnExceeded == nil || !t.quota.OnExceeded(usage) {
+		return false
+	}
+	t.log.Warningf("Storage quota exceeded (%d bytes, %d entries), write allowed by grace callback", usage.Bytes, usage.Entries)
+	return true
+}
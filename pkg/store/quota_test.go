@@ -0,0 +1,125 @@
+/*
+Copyright 2019-2020 vChain, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func makeStoreWithQuota(quota Quota) (*Store, func()) {
+	return makeStoreAt(tmpDir(), func(opts Options) Options {
+		return opts.WithQuota(quota)
+	})
+}
+
+func TestQuotaMaxEntries(t *testing.T) {
+	st, closer := makeStoreWithQuota(Quota{MaxEntries: 3})
+	defer closer()
+
+	_, err := st.Set(schema.KeyValue{Key: []byte(`k1`), Value: []byte(`v1`)})
+	assert.NoError(t, err)
+	_, err = st.ZAdd(schema.ZAddOptions{Set: []byte(`set`), Key: []byte(`k1`), Score: &schema.Score{Score: 1}})
+	assert.NoError(t, err)
+
+	_, err = st.SetBatch(schema.KVList{KVs: []*schema.KeyValue{
+		{Key: []byte(`k2`), Value: []byte(`v2`)},
+		{Key: []byte(`k3`), Value: []byte(`v3`)},
+	}})
+	assert.Equal(t, ErrQuotaExceeded, err)
+
+	_, err = st.Reference(&schema.ReferenceOptions{Reference: []byte(`ref`), Key: []byte(`k1`)})
+	assert.NoError(t, err)
+
+	_, err = st.Set(schema.KeyValue{Key: []byte(`k4`), Value: []byte(`v4`)})
+	assert.Equal(t, ErrQuotaExceeded, err)
+	_, err = st.SafeSet(schema.SafeSetOptions{Kv: &schema.KeyValue{Key: []byte(`k4`), Value: []byte(`v4`)}})
+	assert.Equal(t, ErrQuotaExceeded, err)
+
+	usage := st.QuotaUsage()
+	assert.Equal(t, uint64(3), usage.Entries)
+	assert.Equal(t, uint64(3), usage.MaxEntries)
+}
+
+func TestQuotaGraceCallback(t *testing.T) {
+	var notified []QuotaUsage
+	grace := true
+	st, closer := makeStoreWithQuota(Quota{
+		MaxEntries: 1,
+		OnExceeded: func(usage QuotaUsage) bool {
+			notified = append(notified, usage)
+			return grace
+		},
+	})
+	defer closer()
+
+	_, err := st.Set(schema.KeyValue{Key: []byte(`k1`), Value: []byte(`v1`)})
+	assert.NoError(t, err)
+	assert.Len(t, notified, 0)
+
+	_, err = st.Set(schema.KeyValue{Key: []byte(`k2`), Value: []byte(`v2`)})
+	assert.NoError(t, err)
+	assert.Len(t, notified, 1)
+	assert.Equal(t, uint64(1), notified[0].Entries)
+
+	grace = false
+	_, err = st.Set(schema.KeyValue{Key: []byte(`k3`), Value: []byte(`v3`)})
+	assert.Equal(t, ErrQuotaExceeded, err)
+	assert.Len(t, notified, 2)
+}
+
+func TestQuotaMaxBytes(t *testing.T) {
+	st, closer := makeStoreWithQuota(Quota{MaxBytes: 16})
+	defer closer()
+
+	_, err := st.Set(schema.KeyValue{Key: []byte(`k1`), Value: make([]byte, 32)})
+	assert.Equal(t, ErrQuotaExceeded, err)
+
+	_, err = st.Set(schema.KeyValue{Key: []byte(`k1`), Value: []byte(`v1`)})
+	assert.NoError(t, err)
+}
+
+func TestQuotaMaxEntriesConcurrentWriters(t *testing.T) {
+	st, closer := makeStoreWithQuota(Quota{MaxEntries: 10})
+	defer closer()
+
+	var wg sync.WaitGroup
+	var committed, rejected uint64
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := st.Set(schema.KeyValue{Key: []byte(strconv.Itoa(i)), Value: []byte(`v`)})
+			if err == ErrQuotaExceeded {
+				atomic.AddUint64(&rejected, 1)
+				return
+			}
+			assert.NoError(t, err)
+			atomic.AddUint64(&committed, 1)
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Equal(t, uint64(10), committed)
+	assert.Equal(t, uint64(40), rejected)
+	assert.Equal(t, uint64(10), st.QuotaUsage().Entries)
+}
@@ -27,7 +27,11 @@ func (t *Store) Reference(refOpts *schema.ReferenceOptions, options ...WriteOpti
 		return nil, mapError(err)
 	}
 
-	tsEntry := t.tree.NewEntry(refOpts.Reference, k)
+	ts, err := t.lease(1, int64(len(refOpts.Reference)+len(k)))
+	if err != nil {
+		return nil, err
+	}
+	tsEntry := t.tree.EntryAt(ts, refOpts.Reference, k)
 
 	if err = txn.SetEntry(&badger.Entry{
 		Key:      refOpts.Reference,
@@ -52,7 +52,11 @@ func (t *Store) SafeSet(options schema.SafeSetOptions) (proof *schema.Proof, err
 	txn := t.db.NewTransactionAt(math.MaxUint64, true)
 	defer txn.Discard()
 
-	tsEntry := t.tree.NewEntry(kv.Key, kv.Value)
+	ts, err := t.lease(1, int64(len(kv.Key)+len(kv.Value)))
+	if err != nil {
+		return nil, err
+	}
+	tsEntry := t.tree.EntryAt(ts, kv.Key, kv.Value)
 
 	if err = txn.SetEntry(&badger.Entry{
 		Key:   kv.Key,
@@ -202,7 +206,11 @@ func (t *Store) SafeReference(options schema.SafeReferenceOptions) (proof *schem
 		return nil, mapError(err)
 	}
 
-	tsEntry := t.tree.NewEntry(ro.Reference, k)
+	ts, err := t.lease(1, int64(len(ro.Reference)+len(k)))
+	if err != nil {
+		return nil, err
+	}
+	tsEntry := t.tree.EntryAt(ts, ro.Reference, k)
 
 	if err = txn.SetEntry(&badger.Entry{
 		Key:      ro.Reference,
@@ -275,7 +283,11 @@ func (t *Store) SafeZAdd(options schema.SafeZAddOptions) (proof *schema.Proof, e
 		return nil, err
 	}
 
-	tsEntry := t.tree.NewEntry(ik, referenceValue)
+	ts, err := t.lease(1, int64(len(ik)+len(referenceValue)))
+	if err != nil {
+		return nil, err
+	}
+	tsEntry := t.tree.EntryAt(ts, ik, referenceValue)
 
 	if err = txn.SetEntry(&badger.Entry{
 		Key:      ik,
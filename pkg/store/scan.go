@@ -17,6 +17,7 @@ limitations under the License.
 package store
 
 import (
+	"context"
 	"math"
 
 	"github.com/codenotary/immudb/pkg/api/schema"
@@ -25,6 +26,11 @@ import (
 
 // Scan fetch the entries having the specified key prefix
 func (t *Store) Scan(options schema.ScanOptions) (list *schema.ItemList, err error) {
+	return t.ScanContext(context.Background(), options)
+}
+
+// ScanContext is like Scan, but stops iterating as soon as ctx is done
+func (t *Store) ScanContext(ctx context.Context, options schema.ScanOptions) (list *schema.ItemList, err error) {
 	if isReservedKey(options.Prefix) {
 		return nil, ErrInvalidKeyPrefix
 	}
@@ -70,6 +76,10 @@ func (t *Store) Scan(options schema.ScanOptions) (list *schema.ItemList, err err
 	i := uint64(0)
 
 	for ; it.Valid(); it.Next() {
+		if err = contextErr(ctx); err != nil {
+			return nil, err
+		}
+
 		var item *schema.Item
 
 		if it.Item().UserMeta()&bitReferenceEntry == bitReferenceEntry {
@@ -130,6 +140,11 @@ func (t *Store) Scan(options schema.ScanOptions) (list *schema.ItemList, err err
 
 // ZScan The SCAN command is used in order to incrementally iterate over a collection of elements.
 func (t *Store) ZScan(options schema.ZScanOptions) (list *schema.ZItemList, err error) {
+	return t.ZScanContext(context.Background(), options)
+}
+
+// ZScanContext is like ZScan, but stops iterating as soon as ctx is done
+func (t *Store) ZScanContext(ctx context.Context, options schema.ZScanOptions) (list *schema.ZItemList, err error) {
 	if len(options.Set) == 0 || isReservedKey(options.Set) {
 		return nil, ErrInvalidSet
 	}
@@ -186,6 +201,9 @@ func (t *Store) ZScan(options schema.ZScanOptions) (list *schema.ZItemList, err
 	i := uint64(0)
 
 	for ; it.Valid(); it.Next() {
+		if err = contextErr(ctx); err != nil {
+			return nil, err
+		}
 
 		var zitem *schema.ZItem
 		var item *schema.Item
@@ -260,6 +278,11 @@ func (t *Store) ZScan(options schema.ZScanOptions) (list *schema.ZItemList, err
 
 // IScan iterates over all entries by the insertion order
 func (t *Store) IScan(options schema.IScanOptions) (list *schema.Page, err error) {
+	return t.IScanContext(context.Background(), options)
+}
+
+// IScanContext is like IScan, but stops iterating as soon as ctx is done
+func (t *Store) IScanContext(ctx context.Context, options schema.IScanOptions) (list *schema.Page, err error) {
 	page := &schema.Page{}
 	page.More = true
 
@@ -270,6 +293,10 @@ func (t *Store) IScan(options schema.IScanOptions) (list *schema.Page, err error
 	}
 
 	for {
+		if err := contextErr(ctx); err != nil {
+			return nil, err
+		}
+
 		item, err := t.ByIndex(schema.Index{Index: s})
 		if err != nil {
 			if err == ErrIndexNotFound {
@@ -17,7 +17,9 @@ limitations under the License.
 package store
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	"github.com/codenotary/immudb/pkg/api/schema"
 	"github.com/stretchr/testify/assert"
@@ -463,3 +465,37 @@ func TestStoreIndexReferenceScan(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Exactly(t, 0, len(list.Items))
 }
+
+func TestStoreScanContext(t *testing.T) {
+	st, closer := makeStore()
+	defer closer()
+
+	_, err := st.Set(schema.KeyValue{Key: []byte(`aaa`), Value: []byte(`item1`)})
+	require.NoError(t, err)
+	_, err = st.ZAdd(schema.ZAddOptions{Set: []byte(`set`), Key: []byte(`aaa`), Score: &schema.Score{Score: 1}})
+	require.NoError(t, err)
+	st.tree.WaitUntil(1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = st.ScanContext(ctx, schema.ScanOptions{Prefix: []byte(`a`)})
+	assert.Equal(t, ErrCanceled, err)
+	_, err = st.ZScanContext(ctx, schema.ZScanOptions{Set: []byte(`set`)})
+	assert.Equal(t, ErrCanceled, err)
+	_, err = st.IScanContext(ctx, schema.IScanOptions{PageNumber: 1, PageSize: 1})
+	assert.Equal(t, ErrCanceled, err)
+	_, err = st.HistoryContext(ctx, &schema.HistoryOptions{Key: []byte(`aaa`)})
+	assert.Equal(t, ErrCanceled, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	<-ctx.Done()
+
+	_, err = st.ScanContext(ctx, schema.ScanOptions{Prefix: []byte(`a`)})
+	assert.Equal(t, ErrDeadlineExceeded, err)
+
+	list, err := st.ScanContext(context.Background(), schema.ScanOptions{Prefix: []byte(`a`)})
+	assert.NoError(t, err)
+	assert.Len(t, list.Items, 1)
+}
@@ -22,6 +22,7 @@ import (
 	"crypto/sha256"
 	"math"
 	"sync"
+	"sync/atomic"
 
 	"github.com/codenotary/immudb/pkg/api"
 	"github.com/codenotary/immudb/pkg/api/schema"
@@ -36,33 +37,65 @@ import (
 // Store ...
 type Store struct {
 	sync.RWMutex
-	db   *badger.DB
-	tree *treeStore
-	wg   sync.WaitGroup
-	log  logger.Logger
+	db    *badger.DB
+	tree  *treeStore
+	wg    sync.WaitGroup
+	log   logger.Logger
+	quota Quota
+	keyID string
+	done  chan struct{}
+	bg    sync.WaitGroup // subscriptions and backup schedulers
+
+	closeOnce sync.Once
 }
 
 // Open opens the store with the specified options
-func Open(options Options, badgerOptions badger.Options) (*Store, error) {
+func Open(options Options, badgerOptions badger.Options) (t *Store, err error) {
 	badgerOpts := badgerOptions
 	badgerOpts.ValueDir = badgerOptions.Dir
 	badgerOpts.NumVersionsToKeep = math.MaxInt64 // immutability, always keep all data
 
+	var keyID string
+	var keyRecorded bool
+	if options.keyProvider != nil {
+		// in-memory stores have no directory to record the key id in
+		keyDir := badgerOpts.Dir
+		if badgerOpts.InMemory {
+			keyDir = ""
+		}
+
+		var key []byte
+		if keyID, key, keyRecorded, err = unlock(options.keyProvider, keyDir); err != nil {
+			return nil, err
+		}
+		badgerOpts.EncryptionKey = key
+	}
+
 	db, err := badger.OpenManaged(badgerOpts)
 	if err != nil {
 		return nil, mapError(err)
 	}
 
+	if keyID != "" && !keyRecorded && !badgerOpts.InMemory {
+		if err = recordKeyID(badgerOpts.Dir, keyID); err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+
 	// fixme(leogr): cache size could be calculated using db.MaxBatchCount()
 	tstore, err := newTreeStore(db, 750_000, false, options.log)
 	if err != nil {
 		return nil, err
 	}
 
-	t := &Store{
-		db:   db,
-		tree: tstore,
-		log:  options.log,
+	t = &Store{
+		db:    db,
+		tree:  tstore,
+		log:   options.log,
+		quota: options.quota,
+		keyID: keyID,
+		done:  make(chan struct{}),
 	}
 
 	if t.tree.lastFlushed < t.tree.w {
@@ -101,11 +134,18 @@ func (t *Store) commitPendingTreeEntries() error {
 // Close closes the store
 func (t *Store) Close() error {
 	defer t.log.Debugf("Store closed")
+	t.closeOnce.Do(func() { close(t.done) })
+	t.bg.Wait()
 	t.wg.Wait()
 	t.tree.Close()
 	return t.db.Close()
 }
 
+// KeyID returns the id of the key the store is encrypted with, or an empty string if it's not encrypted
+func (t *Store) KeyID() string {
+	return t.keyID
+}
+
 // Wait ...
 func (t *Store) Wait() {
 	t.wg.Wait()
@@ -136,7 +176,11 @@ func (t *Store) Set(kv schema.KeyValue, options ...WriteOption) (index *schema.I
 	txn := t.db.NewTransactionAt(math.MaxUint64, true)
 	defer txn.Discard()
 
-	tsEntry := t.tree.NewEntry(kv.Key, kv.Value)
+	ts, err := t.lease(1, int64(len(kv.Key)+len(kv.Value)))
+	if err != nil {
+		return nil, err
+	}
+	tsEntry := t.tree.EntryAt(ts, kv.Key, kv.Value)
 
 	if err = txn.SetEntry(&badger.Entry{
 		Key:   kv.Key,
@@ -184,9 +228,71 @@ func (t *Store) Get(key schema.Key) (item *schema.Item, err error) {
 	if err = checkKey(key.Key); err != nil {
 		return nil, err
 	}
-	k := key.Key
 	txn := t.db.NewTransactionAt(math.MaxUint64, false)
 	defer txn.Discard()
+	return t.get(txn, key.Key)
+}
+
+// GetAt fetches the entry having the specified key as it was when the entry at the given index was added
+func (t *Store) GetAt(key schema.Key, index uint64) (item *schema.Item, err error) {
+	if err = checkKey(key.Key); err != nil {
+		return nil, err
+	}
+	if index >= atomic.LoadUint64(&t.tree.ts) {
+		return nil, ErrIndexNotFound
+	}
+	// every entry up to index must be either committed or discarded for the read to be repeatable
+	t.tree.WaitUntil(index)
+
+	txn := t.db.NewTransactionAt(math.MaxUint64, false)
+	defer txn.Discard()
+
+	item, userMeta, err := versionAt(txn, key.Key, index+1)
+	if err != nil {
+		return nil, err
+	}
+
+	if userMeta&bitReferenceEntry == bitReferenceEntry {
+		k, flag, refIndex := UnwrapZIndexReference(item.Value)
+
+		// here check for index reference, if present we resolve reference with itemAt
+		if flag == byte(1) {
+			return t.ByIndex(schema.Index{Index: refIndex})
+		}
+		item, _, err = versionAt(txn, k, index+1)
+	}
+
+	return item, err
+}
+
+// versionAt returns the newest version of key, along with its user meta, written by an entry having a ts not greater than ts.
+// The entries of a batch are all committed at the ts of its last entry, so versions are compared by the ts wrapped
+// within their value rather than by their badger version.
+func versionAt(txn *badger.Txn, key []byte, ts uint64) (item *schema.Item, userMeta byte, err error) {
+	it := txn.NewKeyIterator(key, badger.IteratorOptions{})
+	defer it.Close()
+
+	for it.Rewind(); it.Valid(); it.Next() {
+		if it.Item().IsDeletedOrExpired() {
+			continue
+		}
+		i, err := itemToSchema(key, it.Item())
+		if err != nil {
+			return nil, 0, err
+		}
+		if i.Index < ts && (item == nil || i.Index > item.Index) {
+			item, userMeta = i, it.Item().UserMeta()
+		}
+	}
+
+	if item == nil {
+		return nil, 0, ErrKeyNotFound
+	}
+	return item, userMeta, nil
+}
+
+// get fetches the entry having the specified key as seen by txn, resolving references
+func (t *Store) get(txn *badger.Txn, k []byte) (item *schema.Item, err error) {
 	i, err := txn.Get(k)
 	if err != nil {
 		return nil, mapError(err)
@@ -250,6 +356,20 @@ func (t *Store) Count(prefix schema.KeyPrefix) (count *schema.ItemsCount, err er
 }
 
 func (t *Store) itemAt(readTs uint64) (index uint64, key, value []byte, err error) {
+	index, key, value, hash, err := t.entryAt(readTs)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+
+	// this guard ensure that the insertion order index was not tampered.
+	if hash != api.Digest(index, key, value) {
+		return 0, nil, nil, ErrInconsistentDigest
+	}
+	return index, key, value, nil
+}
+
+// entryAt is like itemAt, but also returns the digest recorded at insertion time without checking it against the value
+func (t *Store) entryAt(readTs uint64) (index uint64, key, value []byte, hash [sha256.Size]byte, err error) {
 	index = readTs - 1
 	var refkey []byte
 
@@ -276,19 +396,18 @@ func (t *Store) itemAt(readTs uint64) (index uint64, key, value []byte, err erro
 			if err == badger.ErrKeyNotFound {
 				err = ErrIndexNotFound
 			}
-			return 0, nil, nil, err
+			return 0, nil, nil, hash, err
 		}
 	}
 
-	var hash [sha256.Size]byte
 	// reference parsing
 	if hash, key, err = decodeRefTreeKey(refkey); err != nil {
-		return 0, nil, nil, err
+		return 0, nil, nil, hash, err
 	}
 
 	if key == nil {
 		// this shouldn't happen
-		return 0, nil, nil, ErrObsoleteDataFormat
+		return 0, nil, nil, hash, ErrObsoleteDataFormat
 	}
 
 	// disk value lookup
@@ -302,7 +421,7 @@ func (t *Store) itemAt(readTs uint64) (index uint64, key, value []byte, err erro
 	for it.Rewind(); it.Valid(); it.Next() {
 		i, err := itemToSchema(key, it.Item())
 		if err != nil {
-			return 0, nil, nil, err
+			return 0, nil, nil, hash, err
 		}
 		// there are multiple possible versions of a key. Choosing the one with the correct timestamp
 		if i.Index == index {
@@ -313,15 +432,10 @@ func (t *Store) itemAt(readTs uint64) (index uint64, key, value []byte, err erro
 
 	if item == nil {
 		// this shouldn't happen
-		return 0, nil, nil, ErrKeyNotFound
+		return 0, nil, nil, hash, ErrKeyNotFound
 	}
 
-	// this guard ensure that the insertion order index was not tampered.
-	realHash := api.Digest(index, key, item.Value)
-	if hash != realHash {
-		return 0, nil, nil, ErrInconsistentDigest
-	}
-	return index, item.Key, item.Value, nil
+	return index, item.Key, item.Value, hash, nil
 }
 
 // ByIndex fetches the entry at the specified index
@@ -338,6 +452,11 @@ func (t *Store) ByIndex(index schema.Index) (item *schema.Item, err error) {
 
 // History fetches the complete history of entries for the specified key
 func (t *Store) History(options *schema.HistoryOptions) (list *schema.ItemList, err error) {
+	return t.HistoryContext(context.Background(), options)
+}
+
+// HistoryContext is like History, but stops iterating as soon as ctx is done
+func (t *Store) HistoryContext(ctx context.Context, options *schema.HistoryOptions) (list *schema.ItemList, err error) {
 	if isReservedKey(options.Key) {
 		err = ErrInvalidKey
 		return
@@ -352,6 +471,10 @@ func (t *Store) History(options *schema.HistoryOptions) (list *schema.ItemList,
 
 	var items []*schema.Item
 	for it.Rewind(); it.Valid(); it.Next() {
+		if err := contextErr(ctx); err != nil {
+			return nil, err
+		}
+
 		item, err := itemToSchema(options.Key, it.Item())
 		if err != nil {
 			return nil, err
@@ -398,7 +521,11 @@ func (t *Store) ZAdd(zaddOpts schema.ZAddOptions, options ...WriteOption) (index
 		return nil, err
 	}
 
-	tsEntry := t.tree.NewEntry(ik, referenceValue)
+	ts, err := t.lease(1, int64(len(ik)+len(referenceValue)))
+	if err != nil {
+		return nil, err
+	}
+	tsEntry := t.tree.EntryAt(ts, ik, referenceValue)
 
 	if err = txn.SetEntry(&badger.Entry{
 		Key:      ik,
@@ -536,20 +663,23 @@ func (t *Store) FlushToDisk() {
 	t.tree.flush()
 }
 
-// Dump returns a dump of the database
+// Dump returns a dump of the database.
+// The tree is locked only while its state is pinned, the dump is then streamed from a snapshot without blocking reads and writes.
 func (t *Store) Dump(kvChan chan *pb.KVList) (err error) {
-	t.tree.Lock()
-	defer t.tree.Unlock()
+	_, err = t.dump(kvChan)
+	return
+}
 
+// dump streams the database into kvChan and returns the root of the tree the dump is consistent with
+func (t *Store) dump(kvChan chan *pb.KVList) (root *schema.Root, err error) {
 	defer close(kvChan)
 
-	if t.tree.w == 0 {
-		return nil
+	root, w := t.pinDump()
+	if w == 0 {
+		return root, nil
 	}
 
-	t.tree.flush()
-
-	stream := t.db.NewStreamAt(t.tree.w)
+	stream := t.db.NewStreamAt(w)
 	stream.NumGo = 16
 	stream.LogPrefix = "Badger.Streaming"
 
@@ -559,7 +689,37 @@ func (t *Store) Dump(kvChan chan *pb.KVList) (err error) {
 	}
 
 	// Run the stream
-	return stream.Orchestrate(context.Background())
+	return root, stream.Orchestrate(context.Background())
+}
+
+// pinDump flushes the tree and returns its root along with the badger version a dump consistent with it must be read at.
+// Batch entries are all committed at the version of their last entry, so while the tree holds only part of a batch
+// the pinning waits for the remaining entries to be added.
+func (t *Store) pinDump() (root *schema.Root, w uint64) {
+	root = schema.NewRoot()
+
+	for {
+		t.tree.Lock()
+		if t.tree.consistent() {
+			break
+		}
+		advanced := t.tree.advanced
+		t.tree.Unlock()
+		<-advanced
+	}
+	defer t.tree.Unlock()
+
+	if t.tree.w == 0 {
+		return root, 0
+	}
+
+	t.tree.flush()
+
+	r := merkletree.Root(t.tree)
+	root.SetRoot(r[:])
+	root.SetIndex(t.tree.w - 1)
+
+	return root, t.tree.w
 }
 
 // Restore restores a database
@@ -581,6 +741,7 @@ func (t *Store) Restore(kvChan chan *pb.KVList) (i uint64, err error) {
 				return i, err
 			}
 			t.tree.loadTreeState()
+			t.tree.notifyAdvance()
 			return t.tree.ts, err
 		} else {
 			err = ldr.Finish()
@@ -43,11 +43,8 @@ func makeStore() (*Store, func()) {
 	return makeStoreAt(tmpDir())
 }
 
-func makeStoreAt(dir string) (*Store, func()) {
-	slog := logger.NewSimpleLoggerWithLevel("bm(immudb)", os.Stderr, logger.LogDebug)
-	opts, badgerOpts := DefaultOptions(dir, slog)
-
-	st, err := Open(opts, badgerOpts)
+func makeStoreAt(dir string, modifiers ...func(Options) Options) (*Store, func()) {
+	st, err := openStoreAt(dir, modifiers...)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -62,6 +59,15 @@ func makeStoreAt(dir string) (*Store, func()) {
 	}
 }
 
+func openStoreAt(dir string, modifiers ...func(Options) Options) (*Store, error) {
+	slog := logger.NewSimpleLoggerWithLevel("bm(immudb)", os.Stderr, logger.LogDebug)
+	opts, badgerOpts := DefaultOptions(dir, slog)
+	for _, modify := range modifiers {
+		opts = modify(opts)
+	}
+	return Open(opts, badgerOpts)
+}
+
 func tmpDir() string {
 	dir, err := ioutil.TempDir("", "immu")
 	if err != nil {
@@ -1024,3 +1030,77 @@ Cras ut magna at tortor vehicula ultrices. Sed lacus metus, tristique sit amet d
 Nulla a dolor in nibh tincidunt blandit. Donec congue, nisl in dictum semper, nunc lectus accumsan dolor, eu consequat velit erat ac libero. Integer ultricies felis purus, vitae sagittis sapien malesuada a. Quisque sed pretium mi. In accumsan enim at urna suscipit ornare. Nunc rhoncus varius diam, nec finibus nunc congue vel. Sed risus urna, pellentesque ut tortor vel, semper lacinia massa. Sed molestie convallis tristique.
 Aenean porta vehicula turpis eget condimentum. Aenean finibus justo vel nisi vestibulum, id placerat leo luctus. Lorem ipsum dolor sit amet, consectetur adipiscing elit. Maecenas a risus et mauris luctus vehicula id vitae lectus. Sed molestie bibendum risus non pretium. Sed a posuere mauris, vitae ornare diam. Praesent ac quam egestas, molestie arcu nec, volutpat lacus. Nulla at sagittis mi. Integer id justo ante. Nulla et metus id mauris finibus volutpat eget sed nisi. Maecenas ac gravida lacus, id feugiat neque. Nullam auctor purus ut dolor euismod, nec congue ante placerat. Donec fermentum orci quis aliquam congue.
 Lorem ipsum dolor sit amet, consectetur adipiscing elit. Nullam tincidunt viverra orci eget ornare. Nam mattis nunc a gravida scelerisque. Phasellus ullamcorper tellus nec tincidunt rhoncus. Nunc ac risus orci. Ut bibendum pharetra neque eu semper. Pellentesque habitant morbi tristique senectus et netus et malesuada fames ac turpis egestas. Etiam convallis lectus non pharetra commodo.`)
+
+func TestStoreGetAt(t *testing.T) {
+	st, closer := makeStore()
+	defer closer()
+
+	_, err := st.GetAt(schema.Key{Key: []byte(`key`)}, 0)
+	assert.Equal(t, ErrIndexNotFound, err)
+
+	for i := 0; i < 3; i++ {
+		_, err := st.Set(schema.KeyValue{Key: []byte(`key`), Value: []byte(strconv.Itoa(i))})
+		assert.NoError(t, err)
+		_, err = st.Set(schema.KeyValue{Key: []byte(`other`), Value: []byte(strconv.Itoa(i))})
+		assert.NoError(t, err)
+	}
+
+	item, err := st.GetAt(schema.Key{Key: []byte(`key`)}, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte(`0`), item.Value)
+	assert.Equal(t, uint64(0), item.Index)
+
+	item, err = st.GetAt(schema.Key{Key: []byte(`key`)}, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte(`1`), item.Value)
+	assert.Equal(t, uint64(2), item.Index)
+
+	item, err = st.GetAt(schema.Key{Key: []byte(`key`)}, 5)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte(`2`), item.Value)
+
+	_, err = st.GetAt(schema.Key{Key: []byte(`other`)}, 0)
+	assert.Equal(t, ErrKeyNotFound, err)
+
+	_, err = st.GetAt(schema.Key{Key: []byte(`key`)}, 6)
+	assert.Equal(t, ErrIndexNotFound, err)
+
+	_, err = st.GetAt(schema.Key{Key: []byte{0}}, 0)
+	assert.Equal(t, ErrInvalidKey, err)
+
+	// batch entries are all committed at the ts of the last one, yet each is visible from its own index
+	_, err = st.SetBatch(schema.KVList{KVs: []*schema.KeyValue{
+		{Key: []byte(`a`), Value: []byte(`1`)},
+		{Key: []byte(`b`), Value: []byte(`2`)},
+	}})
+	assert.NoError(t, err)
+
+	item, err = st.GetAt(schema.Key{Key: []byte(`a`)}, 6)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte(`1`), item.Value)
+	assert.Equal(t, uint64(6), item.Index)
+	_, err = st.GetAt(schema.Key{Key: []byte(`b`)}, 6)
+	assert.Equal(t, ErrKeyNotFound, err)
+	item, err = st.GetAt(schema.Key{Key: []byte(`b`)}, 7)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte(`2`), item.Value)
+
+	_, err = st.ExecAllOps(&schema.Ops{Operations: []*schema.Op{
+		{Operation: &schema.Op_KVs{KVs: &schema.KeyValue{Key: []byte(`a`), Value: []byte(`3`)}}},
+		{Operation: &schema.Op_KVs{KVs: &schema.KeyValue{Key: []byte(`c`), Value: []byte(`4`)}}},
+	}})
+	assert.NoError(t, err)
+
+	item, err = st.GetAt(schema.Key{Key: []byte(`a`)}, 7)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte(`1`), item.Value)
+	item, err = st.GetAt(schema.Key{Key: []byte(`a`)}, 8)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte(`3`), item.Value)
+	assert.Equal(t, uint64(8), item.Index)
+	_, err = st.GetAt(schema.Key{Key: []byte(`c`)}, 8)
+	assert.Equal(t, ErrKeyNotFound, err)
+	item, err = st.GetAt(schema.Key{Key: []byte(`c`)}, 9)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte(`4`), item.Value)
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2019-2020 vChain, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"bytes"
+	"sync"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+)
+
+// SubscribeOptions ...
+type SubscribeOptions struct {
+	keyPrefix    []byte
+	minValueSize int
+}
+
+func makeSubscribeOptions(opts ...SubscribeOption) *SubscribeOptions {
+	so := &SubscribeOptions{}
+	for _, f := range opts {
+		f(so)
+	}
+	return so
+}
+
+// SubscribeOption ...
+type SubscribeOption func(*SubscribeOptions)
+
+// WithKeyPrefix makes a subscription stream only the entries whose key starts with prefix
+func WithKeyPrefix(prefix []byte) SubscribeOption {
+	return func(opts *SubscribeOptions) {
+		opts.keyPrefix = prefix
+	}
+}
+
+// WithMinValueSize makes a subscription stream only the entries whose value is at least size bytes long
+func WithMinValueSize(size int) SubscribeOption {
+	return func(opts *SubscribeOptions) {
+		opts.minValueSize = size
+	}
+}
+
+func (opts *SubscribeOptions) match(item *schema.Item) bool {
+	return bytes.HasPrefix(item.Key, opts.keyPrefix) && len(item.Value) >= opts.minValueSize
+}
+
+// Subscribe streams the entries added to the store in index order, starting from fromIndex.
+// Entries already in the store are sent first, then new entries as soon as they are included in the tree.
+// Indexes leased by writes that were not committed are skipped, as well as the entries not matching the options.
+// The channel is closed when the returned cancel function is called, when the store is closed or on read errors.
+func (t *Store) Subscribe(fromIndex uint64, options ...SubscribeOption) (<-chan *schema.Item, func()) {
+	opts := makeSubscribeOptions(options...)
+	items := make(chan *schema.Item)
+	cancelled := make(chan struct{})
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() { close(cancelled) })
+	}
+
+	t.bg.Add(1)
+	go func() {
+		defer t.bg.Done()
+		defer close(items)
+
+		for index := fromIndex; t.waitForIndex(index, cancelled); index++ {
+			item, err := t.ByIndex(schema.Index{Index: index})
+			if err == ErrKeyNotFound {
+				// see treeStore.Discard
+				continue
+			}
+			if err != nil {
+				t.log.Errorf("Subscription stopped at index %d: %v", index, err)
+				return
+			}
+			if !opts.match(item) {
+				continue
+			}
+
+			select {
+			case items <- item:
+			case <-cancelled:
+				return
+			case <-t.done:
+				return
+			}
+		}
+	}()
+
+	return items, cancel
+}
+
+// waitForIndex is similar to treeStore.WaitUntil but gives up when cancelled or when the store is closed.
+// It returns true once the given index has been added into the tree.
+func (t *Store) waitForIndex(index uint64, cancelled <-chan struct{}) bool {
+	for {
+		w, advanced := t.tree.WidthAdvance()
+		if w > index {
+			return true
+		}
+
+		select {
+		case <-cancelled:
+			return false
+		case <-t.done:
+			return false
+		case <-advanced:
+		}
+	}
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2019-2020 vChain, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubscribe(t *testing.T) {
+	st, closer := makeStore()
+	defer closer()
+
+	_, err := st.Set(schema.KeyValue{Key: []byte(`k0`), Value: []byte(`v0`)})
+	assert.NoError(t, err)
+	_, err = st.Set(schema.KeyValue{Key: []byte(`k1`), Value: []byte(`v1`)})
+	assert.NoError(t, err)
+
+	items, cancel := st.Subscribe(1)
+
+	item := <-items
+	assert.Equal(t, uint64(1), item.Index)
+	assert.Equal(t, []byte(`k1`), item.Key)
+
+	// a conflicting transaction leaves a discarded index, which is skipped
+	tx1 := st.BeginTx()
+	tx2 := st.BeginTx()
+	assert.NoError(t, tx1.Set(schema.KeyValue{Key: []byte(`k`), Value: []byte(`tx1`)}))
+	assert.NoError(t, tx2.Set(schema.KeyValue{Key: []byte(`k`), Value: []byte(`tx2`)}))
+	_, err = tx1.Commit()
+	assert.NoError(t, err)
+	_, err = tx2.Commit()
+	assert.Equal(t, ErrConflict, err)
+
+	for i := 0; i < 3; i++ {
+		_, err = st.Set(schema.KeyValue{Key: []byte(`k` + strconv.Itoa(i+2)), Value: []byte(`v`)})
+		assert.NoError(t, err)
+	}
+
+	item = <-items
+	assert.Equal(t, uint64(2), item.Index)
+	assert.Equal(t, []byte(`tx1`), item.Value)
+
+	for i := 0; i < 3; i++ {
+		item = <-items
+		assert.Equal(t, uint64(i+4), item.Index)
+	}
+
+	cancel()
+	cancel()
+	_, ok := <-items
+	assert.False(t, ok)
+}
+
+func TestSubscribeWithFilters(t *testing.T) {
+	st, closer := makeStore()
+	defer closer()
+
+	kvs := []schema.KeyValue{
+		{Key: []byte(`a:1`), Value: []byte(`v`)},
+		{Key: []byte(`b:1`), Value: []byte(`value`)},
+		{Key: []byte(`a:2`), Value: []byte(`value`)},
+		{Key: []byte(`a`), Value: []byte(`value`)},
+		{Key: []byte(`a:3`), Value: []byte(`longer value`)},
+	}
+	for _, kv := range kvs {
+		_, err := st.Set(kv)
+		assert.NoError(t, err)
+	}
+
+	items, cancel := st.Subscribe(0, WithKeyPrefix([]byte(`a:`)), WithMinValueSize(5))
+	defer cancel()
+
+	item := <-items
+	assert.Equal(t, uint64(2), item.Index)
+	assert.Equal(t, []byte(`a:2`), item.Key)
+
+	item = <-items
+	assert.Equal(t, uint64(4), item.Index)
+	assert.Equal(t, []byte(`a:3`), item.Key)
+
+	// new entries are filtered as well
+	_, err := st.Set(schema.KeyValue{Key: []byte(`b:2`), Value: []byte(`value`)})
+	assert.NoError(t, err)
+	_, err = st.Set(schema.KeyValue{Key: []byte(`a:4`), Value: []byte(`value`)})
+	assert.NoError(t, err)
+
+	item = <-items
+	assert.Equal(t, uint64(6), item.Index)
+	assert.Equal(t, []byte(`a:4`), item.Key)
+}
+
+func TestSubscribeClosedStore(t *testing.T) {
+	dir := tmpDir()
+	defer os.RemoveAll(dir)
+
+	st, _ := makeStoreAt(dir)
+
+	items, cancel := st.Subscribe(0)
+	defer cancel()
+
+	assert.NoError(t, st.Close())
+	_, ok := <-items
+	assert.False(t, ok)
+}
+
+func TestTreeWidthAdvance(t *testing.T) {
+	st, closer := makeStore()
+	defer closer()
+
+	w, advanced := st.tree.WidthAdvance()
+	assert.Equal(t, uint64(0), w)
+
+	_, err := st.Set(schema.KeyValue{Key: []byte(`k`), Value: []byte(`v`)})
+	assert.NoError(t, err)
+
+	select {
+	case <-advanced:
+	case <-time.After(5 * time.Second):
+		t.Fatal("tree width advance not signalled")
+	}
+
+	w, advanced = st.tree.WidthAdvance()
+	assert.Equal(t, uint64(1), w)
+	select {
+	case <-advanced:
+		t.Fatal("tree width advance signalled without new entries")
+	default:
+	}
+}
@@ -127,6 +127,8 @@ type treeStoreEntry struct {
 	ts uint64
 	h  *[sha256.Size]byte
 	r  *[]byte
+	// commitTs is the badger version the entry is committed at, when it differs from ts (ie. batch entries)
+	commitTs uint64
 }
 
 func (t treeStoreEntry) Index() uint64 {
@@ -152,6 +154,8 @@ type treeStore struct {
 	rcache       ring.Buffer
 	cPos         [256]uint64
 	cSize        uint64
+	advanced     chan struct{} // closed and replaced each time w advances
+	committedTs  uint64        // highest badger version of the entries added to the tree
 	sync.RWMutex
 	closeOnce sync.Once
 }
@@ -167,6 +171,7 @@ func newTreeStore(db *badger.DB, cacheSize uint64, flushLeaves bool, log logger.
 		flushLeaves: flushLeaves,
 		cPos:        [256]uint64{},
 		cSize:       cacheSize,
+		advanced:    make(chan struct{}),
 	}
 
 	t.makeCaches()
@@ -274,7 +279,30 @@ func (t *treeStore) LastIndex() uint64 {
 // NewEntry acquires a lease for a new entry and returns it. The entry must be used with Commit() or Discard().
 // It's thread-safe.
 func (t *treeStore) NewEntry(key []byte, value []byte) *treeStoreEntry {
-	ts := atomic.AddUint64(&t.ts, 1)
+	ts, _ := t.Lease(1, 0)
+	return t.EntryAt(ts, key, value)
+}
+
+// Lease reserves n consecutive ts and returns the last one.
+// When max is not zero, nothing is leased and false is returned if the last ts would exceed max.
+// It's thread-safe.
+func (t *treeStore) Lease(n uint64, max uint64) (uint64, bool) {
+	if max == 0 {
+		return atomic.AddUint64(&t.ts, n), true
+	}
+	for {
+		ts := atomic.LoadUint64(&t.ts)
+		if ts+n > max {
+			return ts, false
+		}
+		if atomic.CompareAndSwapUint64(&t.ts, ts, ts+n) {
+			return ts + n, true
+		}
+	}
+}
+
+// EntryAt returns the entry for the given ts, which must have been acquired with Lease.
+func (t *treeStore) EntryAt(ts uint64, key []byte, value []byte) *treeStoreEntry {
 	h := api.Digest(ts-1, key, value)
 	return &treeStoreEntry{
 		ts: ts,
@@ -283,28 +311,19 @@ func (t *treeStore) NewEntry(key []byte, value []byte) *treeStoreEntry {
 	}
 }
 
-// NewBatch is similar to NewEntry but accept a slice of key-value pairs.
-// It's thread-safe.
-func (t *treeStore) NewBatch(kvPairs *schema.KVList) []*treeStoreEntry {
+// BatchAt returns the entries for the given key-value pairs, whose ts must have been acquired with Lease.
+// lease is the last leased ts.
+func (t *treeStore) BatchAt(lease uint64, kvPairs *schema.KVList) []*treeStoreEntry {
 	size := uint64(len(kvPairs.KVs))
 	batch := make([]*treeStoreEntry, 0, size)
-	lease := atomic.AddUint64(&t.ts, size)
 	for i, kv := range kvPairs.KVs {
 		ts := lease - size + uint64(i) + 1
 		h := api.Digest(ts-1, kv.Key, kv.Value)
-		batch = append(batch, &treeStoreEntry{ts, &h, &kv.Key})
+		batch = append(batch, &treeStoreEntry{ts: ts, h: &h, r: &kv.Key, commitTs: lease})
 	}
 	return batch
 }
 
-// NewOpsTsRange return the initial ts to be used inside batch ops
-// It's thread-safe.
-func (t *treeStore) NewOpsTsRange(ops *schema.Ops) uint64 {
-	size := uint64(len(ops.Operations))
-	lease := atomic.AddUint64(&t.ts, size)
-	return lease - size
-}
-
 // Commit enqueues the given entry to be included in the merkletree.
 // The value of _entry_ might change once Discard() or Commit() is called,
 // so it must not be used later.
@@ -330,6 +349,7 @@ func (t *treeStore) worker() {
 		heap.Push(&pq, item)
 
 		t.Lock()
+		w := t.w
 		for min := pq.Min(); min == t.w+1; min = pq.Min() {
 
 			item := heap.Pop(&pq).(*treeStoreEntry)
@@ -340,10 +360,16 @@ func (t *treeStore) worker() {
 			t.rcache.Set(item.ts-1, c)
 
 			merkletree.AppendHash(t, item.h)
+			if item.commitTs > t.committedTs {
+				t.committedTs = item.commitTs
+			}
 			if t.w%2 == 0 && (t.w-t.lastFlushed) >= t.cSize/2 {
 				t.flush()
 			}
 		}
+		if t.w != w {
+			t.notifyAdvance()
+		}
 		t.Unlock()
 	}
 
@@ -355,6 +381,27 @@ func (t *treeStore) worker() {
 	t.quit <- struct{}{}
 }
 
+// notifyAdvance wakes up the goroutines waiting for the tree to grow, see WidthAdvance.
+// It should be only called when _t_ is locked.
+func (t *treeStore) notifyAdvance() {
+	close(t.advanced)
+	t.advanced = make(chan struct{})
+}
+
+// consistent reports whether the tree covers exactly the entries committed in badger up to version w, that is,
+// whether the tree does not hold only part of a batch.
+// It should be only called when _t_ is locked.
+func (t *treeStore) consistent() bool {
+	return t.committedTs <= t.w
+}
+
+// WidthAdvance returns the width of the tree along with a channel closed as soon as the width changes
+func (t *treeStore) WidthAdvance() (uint64, <-chan struct{}) {
+	t.RLock()
+	defer t.RUnlock()
+	return t.w, t.advanced
+}
+
 // flush should be only called when the tree is in a consistent state and _t_ is locked.
 // It always flushes the last portion (ie. items not yet flushed) of buffers in batch,
 // in case of failure previous stored state will be preserved and cache indexes will be not advanced.
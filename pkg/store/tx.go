@@ -0,0 +1,206 @@
+/*
+Copyright 2019-2020 vChain, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/dgraph-io/badger/v2"
+)
+
+// TxOptions ...
+type TxOptions struct {
+	validateReads bool
+}
+
+func makeTxOptions(opts ...TxOption) *TxOptions {
+	to := &TxOptions{}
+	for _, f := range opts {
+		f(to)
+	}
+	return to
+}
+
+// TxOption ...
+type TxOption func(*TxOptions)
+
+// WithReadSetValidation makes Commit fail with ErrTxReadConflict when any key read by the transaction
+// has been modified after the snapshot was taken, in addition to the write-write conflict detection
+func WithReadSetValidation(validate bool) TxOption {
+	return func(opts *TxOptions) {
+		opts.validateReads = validate
+	}
+}
+
+// Tx is an interactive transaction.
+// Reads are served from the snapshot pinned by BeginTx, overlaid with the writes done within the transaction itself.
+// Writes are buffered in memory and atomically committed by Commit, which fails with ErrConflict
+// if any of the written keys has been committed by someone else after the snapshot was taken.
+// Conflicts are detected against plain writes too: Commit waits for the writes leased before it to complete,
+// so a concurrent Set can't be committed at a lower index after the transaction, shadowing it.
+// When created WithReadSetValidation, the keys read by the transaction are validated the same way.
+type Tx struct {
+	sync.Mutex
+	st         *Store
+	opts       *TxOptions
+	snapshotTs uint64
+	txn        *badger.Txn
+	kvs        []*schema.KeyValue
+	writes     map[string]int
+	closed     bool
+}
+
+// BeginTx starts a new interactive transaction pinned to the latest entry.
+// The transaction must be terminated by either Commit or Discard.
+func (t *Store) BeginTx(options ...TxOption) *Tx {
+	// once the tree reaches the last leased entry, every entry up to it has been either committed or discarded,
+	// so reading at it gives a consistent snapshot
+	snapshotTs := atomic.LoadUint64(&t.tree.ts)
+	if snapshotTs > 0 {
+		t.tree.WaitUntil(snapshotTs - 1)
+	}
+
+	return &Tx{
+		st:         t,
+		opts:       makeTxOptions(options...),
+		snapshotTs: snapshotTs,
+		txn:        t.db.NewTransactionAt(snapshotTs, true),
+		writes:     make(map[string]int),
+	}
+}
+
+// SnapshotIndex returns the number of entries visible to the transaction
+func (tx *Tx) SnapshotIndex() uint64 {
+	return tx.snapshotTs
+}
+
+// Get fetches the entry having the specified key, as seen by the transaction.
+// Keys written by the transaction are returned with the value set, without an index since it's not yet assigned.
+func (tx *Tx) Get(key schema.Key) (*schema.Item, error) {
+	if err := checkKey(key.Key); err != nil {
+		return nil, err
+	}
+
+	tx.Lock()
+	defer tx.Unlock()
+
+	if tx.closed {
+		return nil, ErrDiscardedTxn
+	}
+
+	if i, ok := tx.writes[string(key.Key)]; ok {
+		return &schema.Item{Key: tx.kvs[i].Key, Value: tx.kvs[i].Value}, nil
+	}
+
+	if tx.opts.validateReads {
+		// reads done through the update txn are tracked for conflict detection
+		return tx.st.get(tx.txn, key.Key)
+	}
+
+	rtxn := tx.st.db.NewTransactionAt(tx.snapshotTs, false)
+	defer rtxn.Discard()
+
+	return tx.st.get(rtxn, key.Key)
+}
+
+// Set buffers a new entry, a later Set on the same key within the transaction replaces the previous one
+func (tx *Tx) Set(kv schema.KeyValue) error {
+	if err := checkKey(kv.Key); err != nil {
+		return err
+	}
+
+	tx.Lock()
+	defer tx.Unlock()
+
+	if tx.closed {
+		return ErrDiscardedTxn
+	}
+
+	entry := &schema.KeyValue{
+		Key:   append([]byte{}, kv.Key...),
+		Value: append([]byte{}, kv.Value...),
+	}
+	if i, ok := tx.writes[string(kv.Key)]; ok {
+		tx.kvs[i] = entry
+		return nil
+	}
+	tx.writes[string(kv.Key)] = len(tx.kvs)
+	tx.kvs = append(tx.kvs, entry)
+	return nil
+}
+
+// Commit atomically persists all the entries set within the transaction and returns the index of the last one.
+// ErrConflict is returned when any of the written keys has been modified after the snapshot was taken,
+// ErrTxReadConflict when only keys read by a transaction validating its read set have been.
+// Committing a transaction without writes is a no-op returning a nil index.
+func (tx *Tx) Commit(options ...WriteOption) (index *schema.Index, err error) {
+	tx.Lock()
+	defer tx.Unlock()
+
+	if tx.closed {
+		return nil, ErrDiscardedTxn
+	}
+	tx.closed = true
+
+	if len(tx.kvs) == 0 {
+		tx.txn.Discard()
+		return nil, nil
+	}
+
+	// written keys are tracked as read in order to detect write-write conflicts
+	for _, kv := range tx.kvs {
+		if _, err := tx.txn.Get(kv.Key); err != nil && err != badger.ErrKeyNotFound {
+			tx.txn.Discard()
+			return nil, mapError(err)
+		}
+	}
+
+	index, err = tx.st.commitBatch(tx.txn, schema.KVList{KVs: tx.kvs}, makeWriteOptions(options...), true)
+	if err == ErrConflict && tx.opts.validateReads && !tx.hasWriteConflict() {
+		return nil, ErrTxReadConflict
+	}
+	return index, err
+}
+
+// hasWriteConflict tells whether any of the written keys has a version newer than the snapshot
+func (tx *Tx) hasWriteConflict() bool {
+	txn := tx.st.db.NewTransactionAt(math.MaxUint64, false)
+	defer txn.Discard()
+
+	for _, kv := range tx.kvs {
+		if i, err := txn.Get(kv.Key); err == nil && i.Version() > tx.snapshotTs {
+			return true
+		}
+	}
+	return false
+}
+
+// Discard releases the transaction without persisting its entries.
+// It's safe to call Discard after Commit.
+func (tx *Tx) Discard() {
+	tx.Lock()
+	defer tx.Unlock()
+
+	if tx.closed {
+		return
+	}
+	tx.closed = true
+	tx.txn.Discard()
+}
@@ -0,0 +1,253 @@
+/*
+Copyright 2019-2020 vChain, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/dgraph-io/badger/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTxReadYourWrites(t *testing.T) {
+	st, closer := makeStore()
+	defer closer()
+
+	_, err := st.Set(schema.KeyValue{Key: []byte(`k1`), Value: []byte(`v1`)})
+	assert.NoError(t, err)
+
+	tx := st.BeginTx()
+	assert.Equal(t, uint64(1), tx.SnapshotIndex())
+
+	item, err := tx.Get(schema.Key{Key: []byte(`k1`)})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte(`v1`), item.Value)
+
+	assert.NoError(t, tx.Set(schema.KeyValue{Key: []byte(`k1`), Value: []byte(`v2`)}))
+	assert.NoError(t, tx.Set(schema.KeyValue{Key: []byte(`k2`), Value: []byte(`v3`)}))
+	assert.NoError(t, tx.Set(schema.KeyValue{Key: []byte(`k2`), Value: []byte(`v4`)}))
+	assert.Equal(t, ErrInvalidKey, tx.Set(schema.KeyValue{Key: []byte{0}}))
+
+	item, err = tx.Get(schema.Key{Key: []byte(`k2`)})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte(`v4`), item.Value)
+
+	// not visible outside the transaction until committed
+	_, err = st.Get(schema.Key{Key: []byte(`k2`)})
+	assert.Equal(t, ErrKeyNotFound, err)
+
+	index, err := tx.Commit()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), index.Index)
+
+	item, err = st.Get(schema.Key{Key: []byte(`k1`)})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte(`v2`), item.Value)
+
+	item, err = st.Get(schema.Key{Key: []byte(`k2`)})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte(`v4`), item.Value)
+	assert.Equal(t, uint64(2), item.Index)
+
+	_, err = tx.Commit()
+	assert.Equal(t, ErrDiscardedTxn, err)
+	assert.Equal(t, ErrDiscardedTxn, tx.Set(schema.KeyValue{Key: []byte(`k3`)}))
+	_, err = tx.Get(schema.Key{Key: []byte(`k1`)})
+	assert.Equal(t, ErrDiscardedTxn, err)
+	tx.Discard()
+}
+
+func TestTxSnapshotIsolation(t *testing.T) {
+	st, closer := makeStore()
+	defer closer()
+
+	_, err := st.Set(schema.KeyValue{Key: []byte(`k1`), Value: []byte(`v1`)})
+	assert.NoError(t, err)
+
+	tx := st.BeginTx()
+
+	_, err = st.Set(schema.KeyValue{Key: []byte(`k1`), Value: []byte(`v2`)})
+	assert.NoError(t, err)
+	_, err = st.Set(schema.KeyValue{Key: []byte(`k2`), Value: []byte(`v1`)})
+	assert.NoError(t, err)
+
+	item, err := tx.Get(schema.Key{Key: []byte(`k1`)})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte(`v1`), item.Value)
+
+	_, err = tx.Get(schema.Key{Key: []byte(`k2`)})
+	assert.Equal(t, ErrKeyNotFound, err)
+
+	// reading a key modified after the snapshot is not a conflict
+	assert.NoError(t, tx.Set(schema.KeyValue{Key: []byte(`k3`), Value: []byte(`v1`)}))
+	_, err = tx.Commit()
+	assert.NoError(t, err)
+}
+
+func TestTxWriteConflict(t *testing.T) {
+	st, closer := makeStore()
+	defer closer()
+
+	tx1 := st.BeginTx()
+	tx2 := st.BeginTx()
+
+	assert.NoError(t, tx1.Set(schema.KeyValue{Key: []byte(`k1`), Value: []byte(`tx1`)}))
+	assert.NoError(t, tx2.Set(schema.KeyValue{Key: []byte(`k1`), Value: []byte(`tx2`)}))
+	assert.NoError(t, tx2.Set(schema.KeyValue{Key: []byte(`k2`), Value: []byte(`tx2`)}))
+
+	_, err := tx1.Commit()
+	assert.NoError(t, err)
+
+	_, err = tx2.Commit()
+	assert.Equal(t, ErrConflict, err)
+
+	item, err := st.Get(schema.Key{Key: []byte(`k1`)})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte(`tx1`), item.Value)
+
+	_, err = st.Get(schema.Key{Key: []byte(`k2`)})
+	assert.Equal(t, ErrKeyNotFound, err)
+
+	// the discarded entries must not stall the tree
+	index, err := st.Set(schema.KeyValue{Key: []byte(`k3`), Value: []byte(`v`)})
+	assert.NoError(t, err)
+	st.tree.WaitUntil(index.Index)
+}
+
+func TestTxWriteConflictWithPlainWrite(t *testing.T) {
+	st, closer := makeStore()
+	defer closer()
+
+	tx := st.BeginTx()
+	assert.NoError(t, tx.Set(schema.KeyValue{Key: []byte(`k1`), Value: []byte(`tx`)}))
+
+	// a plain write leases its index before the transaction commits, but is committed after it
+	lease, _ := st.tree.Lease(1, 0)
+	entries := st.tree.BatchAt(lease, &schema.KVList{KVs: []*schema.KeyValue{
+		{Key: []byte(`k1`), Value: []byte(`set`)},
+	}})
+
+	committed := make(chan error, 1)
+	go func() {
+		_, err := tx.Commit()
+		committed <- err
+	}()
+
+	select {
+	case <-committed:
+		t.Fatal("transaction committed ahead of a preceding write")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	txn := st.db.NewTransactionAt(math.MaxUint64, true)
+	assert.NoError(t, txn.SetEntry(&badger.Entry{Key: []byte(`k1`), Value: WrapValueWithTS([]byte(`set`), entries[0].ts)}))
+	assert.NoError(t, txn.CommitAt(entries[0].ts, nil))
+	st.tree.Commit(entries[0])
+
+	assert.Equal(t, ErrConflict, <-committed)
+
+	item, err := st.Get(schema.Key{Key: []byte(`k1`)})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte(`set`), item.Value)
+}
+
+func TestTxDiscard(t *testing.T) {
+	st, closer := makeStore()
+	defer closer()
+
+	tx := st.BeginTx()
+	assert.NoError(t, tx.Set(schema.KeyValue{Key: []byte(`k1`), Value: []byte(`v1`)}))
+	tx.Discard()
+	tx.Discard()
+
+	_, err := st.Get(schema.Key{Key: []byte(`k1`)})
+	assert.Equal(t, ErrKeyNotFound, err)
+
+	index, err := st.BeginTx().Commit()
+	assert.NoError(t, err)
+	assert.Nil(t, index)
+}
+
+func TestTxReadConflict(t *testing.T) {
+	st, closer := makeStore()
+	defer closer()
+
+	_, err := st.Set(schema.KeyValue{Key: []byte(`balance`), Value: []byte(`10`)})
+	assert.NoError(t, err)
+
+	tx := st.BeginTx(WithReadSetValidation(true))
+	item, err := tx.Get(schema.Key{Key: []byte(`balance`)})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte(`10`), item.Value)
+	_, err = tx.Get(schema.Key{Key: []byte(`missing`)})
+	assert.Equal(t, ErrKeyNotFound, err)
+
+	_, err = st.Set(schema.KeyValue{Key: []byte(`balance`), Value: []byte(`20`)})
+	assert.NoError(t, err)
+
+	assert.NoError(t, tx.Set(schema.KeyValue{Key: []byte(`audit`), Value: []byte(`10`)}))
+	_, err = tx.Commit()
+	assert.Equal(t, ErrTxReadConflict, err)
+
+	_, err = st.Get(schema.Key{Key: []byte(`audit`)})
+	assert.Equal(t, ErrKeyNotFound, err)
+
+	// without read set validation the same workflow commits
+	tx = st.BeginTx()
+	_, err = tx.Get(schema.Key{Key: []byte(`balance`)})
+	assert.NoError(t, err)
+	_, err = st.Set(schema.KeyValue{Key: []byte(`balance`), Value: []byte(`30`)})
+	assert.NoError(t, err)
+	assert.NoError(t, tx.Set(schema.KeyValue{Key: []byte(`audit`), Value: []byte(`20`)}))
+	_, err = tx.Commit()
+	assert.NoError(t, err)
+}
+
+func TestTxReadConflictOnAbsentKey(t *testing.T) {
+	st, closer := makeStore()
+	defer closer()
+
+	tx := st.BeginTx(WithReadSetValidation(true))
+	_, err := tx.Get(schema.Key{Key: []byte(`k1`)})
+	assert.Equal(t, ErrKeyNotFound, err)
+
+	_, err = st.Set(schema.KeyValue{Key: []byte(`k1`), Value: []byte(`v1`)})
+	assert.NoError(t, err)
+
+	assert.NoError(t, tx.Set(schema.KeyValue{Key: []byte(`k2`), Value: []byte(`v2`)}))
+	_, err = tx.Commit()
+	assert.Equal(t, ErrTxReadConflict, err)
+}
+
+func TestTxWriteConflictWithReadSetValidation(t *testing.T) {
+	st, closer := makeStore()
+	defer closer()
+
+	tx := st.BeginTx(WithReadSetValidation(true))
+	_, err := tx.Get(schema.Key{Key: []byte(`k1`)})
+	assert.Equal(t, ErrKeyNotFound, err)
+
+	_, err = st.Set(schema.KeyValue{Key: []byte(`k1`), Value: []byte(`v1`)})
+	assert.NoError(t, err)
+
+	assert.NoError(t, tx.Set(schema.KeyValue{Key: []byte(`k1`), Value: []byte(`v2`)}))
+	_, err = tx.Commit()
+	assert.Equal(t, ErrConflict, err)
+}
@@ -0,0 +1,179 @@
+/*
+Copyright 2019-2020 vChain, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"crypto/sha256"
+	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/codenotary/immudb/pkg/api"
+	"github.com/codenotary/merkletree"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrLeafMismatch is returned when the tree leaf of an entry does not match the digest of its key and value
+var ErrLeafMismatch = status.New(codes.DataLoss, "tree leaf is not equal to the digest of the related entry").Err()
+
+// ErrInclusionProofFailed is returned when an entry cannot be proven to be included in the tree
+var ErrInclusionProofFailed = status.New(codes.DataLoss, "inclusion proof verification failed").Err()
+
+// VerifyCheck selects the checks performed by VerifyRange on each entry
+type VerifyCheck uint8
+
+const (
+	// VerifyValueDigest checks the value of the entry against the digest recorded at insertion time
+	VerifyValueDigest VerifyCheck = 1 << iota
+	// VerifyLeaf checks the tree leaf of the entry against the recorded digest
+	VerifyLeaf
+	// VerifyInclusion checks the inclusion proof of the entry in the tree at the pinned root
+	VerifyInclusion
+
+	// VerifyAll performs all the checks
+	VerifyAll = VerifyValueDigest | VerifyLeaf | VerifyInclusion
+)
+
+// VerifyFailure reports an entry that failed verification
+type VerifyFailure struct {
+	Index uint64
+	Err   error
+}
+
+// VerifyReport is the outcome of VerifyRange
+type VerifyReport struct {
+	From     uint64
+	To       uint64
+	At       uint64
+	Root     [sha256.Size]byte
+	Verified uint64
+	// Discarded counts the entries whose index was leased by a write that has not been committed
+	Discarded uint64
+	Failures  []VerifyFailure
+}
+
+// VerifyRange verifies the entries at the indexes [from, to] against the current root using the given number of workers.
+// checks selects whether each entry is checked for a value matching the digest recorded at insertion time (VerifyValueDigest),
+// for a tree leaf matching that digest (VerifyLeaf) and for an inclusion proof in the tree at the root pinned when the
+// verification starts (VerifyInclusion). Zero workers use one per CPU, zero checks perform them all.
+// Failed entries are collected in the report, sorted by index, while an error is only returned for invalid ranges.
+func (t *Store) VerifyRange(from, to uint64, workers int, checks VerifyCheck) (*VerifyReport, error) {
+	if from > to {
+		return nil, status.New(codes.InvalidArgument, "invalid range").Err()
+	}
+	if checks&^VerifyAll != 0 {
+		return nil, status.New(codes.InvalidArgument, "invalid checks").Err()
+	}
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	if checks == 0 {
+		checks = VerifyAll
+	}
+
+	if to >= atomic.LoadUint64(&t.tree.ts) {
+		return nil, ErrIndexNotFound
+	}
+	t.tree.WaitUntil(to)
+
+	t.tree.RLock()
+	at := t.tree.w - 1
+	root := merkletree.Root(t.tree)
+	t.tree.RUnlock()
+
+	report := &VerifyReport{From: from, To: to, At: at, Root: root}
+
+	var mtx sync.Mutex
+	var wg sync.WaitGroup
+	indexes := make(chan uint64, workers)
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indexes {
+				discarded, err := t.verifyEntry(index, at, root, checks)
+				mtx.Lock()
+				switch {
+				case err != nil:
+					report.Failures = append(report.Failures, VerifyFailure{Index: index, Err: err})
+				case discarded:
+					report.Discarded++
+				default:
+					report.Verified++
+				}
+				mtx.Unlock()
+			}
+		}()
+	}
+
+	for index := from; index <= to; index++ {
+		indexes <- index
+	}
+	close(indexes)
+	wg.Wait()
+
+	sort.Slice(report.Failures, func(i, j int) bool {
+		return report.Failures[i].Index < report.Failures[j].Index
+	})
+
+	return report, nil
+}
+
+func (t *Store) verifyEntry(index, at uint64, root [sha256.Size]byte, checks VerifyCheck) (discarded bool, err error) {
+	_, key, value, digest, err := t.entryAt(index + 1)
+	switch err {
+	case nil:
+		if checks&VerifyValueDigest != 0 && digest != api.Digest(index, key, value) {
+			return false, ErrInconsistentDigest
+		}
+	case ErrKeyNotFound:
+		// see treeStore.Discard
+		digest = api.Digest(index+1, []byte{}, []byte{})
+		discarded = true
+	default:
+		return false, err
+	}
+
+	// discarded entries are told apart from missing ones by their leaf
+	if checks&(VerifyLeaf|VerifyInclusion) == 0 && !discarded {
+		return false, nil
+	}
+
+	t.tree.RLock()
+	leaf := t.tree.Get(0, index)
+	var path merkletree.Path
+	if checks&VerifyInclusion != 0 {
+		path = merkletree.InclusionProof(t.tree, at, index)
+	}
+	t.tree.RUnlock()
+
+	if checks&VerifyLeaf != 0 || discarded {
+		if leaf == nil || *leaf != digest {
+			if discarded {
+				return false, ErrKeyNotFound
+			}
+			return false, ErrLeafMismatch
+		}
+	}
+	if checks&VerifyInclusion != 0 && !path.VerifyInclusion(at, index, root, digest) {
+		return false, ErrInclusionProofFailed
+	}
+	return discarded, nil
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2019-2020 vChain, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/dgraph-io/badger/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyRange(t *testing.T) {
+	st, closer := makeStore()
+	defer closer()
+
+	_, err := st.VerifyRange(0, 0, 1, VerifyAll)
+	assert.Equal(t, ErrIndexNotFound, err)
+
+	for i := 0; i < 100; i++ {
+		_, err := st.Set(schema.KeyValue{Key: []byte(strconv.Itoa(i)), Value: []byte(strconv.Itoa(i))})
+		assert.NoError(t, err)
+	}
+
+	// a conflicting transaction leaves a discarded entry in the tree
+	tx1 := st.BeginTx()
+	tx2 := st.BeginTx()
+	assert.NoError(t, tx1.Set(schema.KeyValue{Key: []byte(`k`), Value: []byte(`tx1`)}))
+	assert.NoError(t, tx2.Set(schema.KeyValue{Key: []byte(`k`), Value: []byte(`tx2`)}))
+	_, err = tx1.Commit()
+	assert.NoError(t, err)
+	_, err = tx2.Commit()
+	assert.Equal(t, ErrConflict, err)
+
+	report, err := st.VerifyRange(0, 101, 4, VerifyAll)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(101), report.At)
+	assert.Equal(t, uint64(101), report.Verified)
+	assert.Equal(t, uint64(1), report.Discarded)
+	assert.Empty(t, report.Failures)
+
+	_, err = st.VerifyRange(10, 5, 4, VerifyAll)
+	assert.Error(t, err)
+	_, err = st.VerifyRange(0, 102, 4, VerifyAll)
+	assert.Equal(t, ErrIndexNotFound, err)
+	_, err = st.VerifyRange(0, 10, 4, VerifyAll+1)
+	assert.Error(t, err)
+
+	// tamper the value of the entry at index 42
+	txn := st.db.NewTransactionAt(43, true)
+	assert.NoError(t, txn.SetEntry(&badger.Entry{Key: []byte(`42`), Value: WrapValueWithTS([]byte(`tampered`), 43)}))
+	assert.NoError(t, txn.CommitAt(43, nil))
+
+	report, err = st.VerifyRange(40, 50, 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(10), report.Verified)
+	assert.Len(t, report.Failures, 1)
+	assert.Equal(t, uint64(42), report.Failures[0].Index)
+	assert.Equal(t, ErrInconsistentDigest, report.Failures[0].Err)
+
+	// the tree still holds the digest recorded at insertion time, so only the value check detects the tampering
+	report, err = st.VerifyRange(40, 50, 0, VerifyLeaf|VerifyInclusion)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(11), report.Verified)
+	assert.Empty(t, report.Failures)
+
+	report, err = st.VerifyRange(40, 50, 0, VerifyValueDigest)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(10), report.Verified)
+	assert.Len(t, report.Failures, 1)
+	assert.Equal(t, ErrInconsistentDigest, report.Failures[0].Err)
+}